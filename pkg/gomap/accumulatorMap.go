@@ -0,0 +1,51 @@
+package gomap
+
+// AccumulatorMap represents a Map that folds many updates into per-key
+// accumulated values, e.g. for aggregating metrics.
+type AccumulatorMap interface {
+	Map
+
+	// Add atomically folds delta into the accumulated value for key, starting
+	// from zero if key is absent, and returns the new accumulated value.
+	Add(key interface{}, delta interface{}) interface{}
+
+	// Snapshot returns all accumulated values, read from a consistent view.
+	Snapshot() map[interface{}]interface{}
+}
+
+// Updates go through a sharded map, so that Add calls for different keys rarely
+// contend with each other.
+type accumulatorMap struct {
+	ConcurrentMap
+	zero       func() interface{}
+	accumulate func(acc, delta interface{}) interface{}
+}
+
+func (am *accumulatorMap) Add(key interface{}, delta interface{}) interface{} {
+	_, acc := am.GetModifySet(key, func(acc interface{}, found bool) interface{} {
+		if !found {
+			acc = am.zero()
+		}
+
+		return am.accumulate(acc, delta)
+	})
+
+	return acc
+}
+
+func (am *accumulatorMap) Snapshot() map[interface{}]interface{} {
+	snapshot := make(map[interface{}]interface{})
+	am.CopyTo(snapshot)
+	return snapshot
+}
+
+// NewAccumulatorMap returns a new AccumulatorMap that starts each key from
+// zero() and folds deltas in with accumulate, which must not call back into the
+// map.
+func NewAccumulatorMap(zero func() interface{}, accumulate func(acc, delta interface{}) interface{}) AccumulatorMap {
+	return &accumulatorMap{
+		ConcurrentMap: NewDefaultShardedConcurrentMap(),
+		zero:          zero,
+		accumulate:    accumulate,
+	}
+}
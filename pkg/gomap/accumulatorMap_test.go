@@ -0,0 +1,51 @@
+package gomap
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAccumulatorMapConcurrentAdd(t *testing.T) {
+	/// Setup
+	am := NewAccumulatorMap(func() interface{} { return 0 }, func(acc, delta interface{}) interface{} {
+		return acc.(int) + delta.(int)
+	})
+
+	keyCount := 10
+	goroutineCount := 20
+	addCount := 100
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(goroutineCount)
+
+	/// When
+	for i := 0; i < goroutineCount; i++ {
+		go func() {
+			defer waitGroup.Done()
+
+			for j := 0; j < addCount; j++ {
+				am.Add(j%keyCount, j%keyCount)
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+
+	/// Then
+	snapshot := am.Snapshot()
+
+	if len(snapshot) != keyCount {
+		t.Errorf("Should accumulate every key, but got %v", snapshot)
+	}
+
+	for key := 0; key < keyCount; key++ {
+		expected := key * goroutineCount * addCount / keyCount
+
+		if snapshot[key] != expected {
+			t.Errorf("Should accumulate %d for %d exactly, but got %v", expected, key, snapshot[key])
+		}
+	}
+
+	if acc := am.Add("new", 5); acc != 5 {
+		t.Errorf("Should start from zero, but got %v", acc)
+	}
+}
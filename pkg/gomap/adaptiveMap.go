@@ -0,0 +1,239 @@
+package gomap
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// AdaptiveMap represents a ConcurrentMap that starts out as a
+// ChannelConcurrentMap and promotes itself to a sharded ConcurrentMap once it
+// detects sustained contention, migrating existing entries transparently.
+type AdaptiveMap interface {
+	ConcurrentMap
+
+	// Close stops the loop goroutine if the map has not been promoted yet. The
+	// map must not be used afterwards.
+	Close()
+
+	// Mode returns ChannelConcurrentMapKind before promotion, and
+	// ShardedConcurrentMapKind afterwards.
+	Mode() MapKind
+
+	// SetConcurrencyMode switches the backing map to ChannelConcurrentMapKind,
+	// LockConcurrentMapKind or ShardedConcurrentMapKind, migrating existing
+	// entries once in-flight operations finish. Automatic promotion is disabled
+	// from then on. This returns ErrUnsupportedMode for any other kind, and
+	// ErrMapClosed if the map has been closed.
+	SetConcurrencyMode(mode MapKind) error
+}
+
+// AdaptiveMapParams configures an AdaptiveMap.
+type AdaptiveMapParams struct {
+	// ContentionThreshold is the number of callers inside the map at the same
+	// time at which an operation counts as contended. Defaults to 16.
+	ContentionThreshold uint
+
+	// ShardCount is the number of shards used after promotion. Defaults to
+	// ContentionThreshold.
+	ShardCount uint
+}
+
+// The number of contended operations after which an AdaptiveMap is promoted,
+// so that a short burst does not trigger promotion.
+const adaptivePromoteAfter = 64
+
+// Every operation holds the read lock while it runs against current, so that
+// promotion can swap current once in-flight operations finish. Contention is
+// measured by counting the callers that are inside the map, which includes
+// those queued on the loop goroutine.
+type adaptiveMap struct {
+	*concurrentOps
+	mutex      sync.RWMutex
+	current    storageAccessor
+	mode       MapKind
+	closed     bool
+	pinned     bool
+	shardCount uint
+	threshold  int32
+	inFlight   int32
+	contended  int32
+}
+
+// This returns a function that the caller must defer, which may promote the
+// map once the caller has left it.
+func (am *adaptiveMap) enter() func() {
+	am.mutex.RLock()
+	inFlight := atomic.AddInt32(&am.inFlight, 1)
+
+	promote := am.mode == ChannelConcurrentMapKind &&
+		inFlight >= am.threshold &&
+		atomic.AddInt32(&am.contended, 1) == adaptivePromoteAfter
+
+	return func() {
+		atomic.AddInt32(&am.inFlight, -1)
+		am.mutex.RUnlock()
+
+		if promote {
+			am.promote()
+		}
+	}
+}
+
+func (am *adaptiveMap) promote() {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	if !am.closed && !am.pinned && am.mode == ChannelConcurrentMapKind {
+		am.switchTo(ShardedConcurrentMapKind)
+	}
+}
+
+// This must be called with the write lock held, and mode must be supported.
+func (am *adaptiveMap) switchTo(mode MapKind) {
+	if mode == am.mode {
+		return
+	}
+
+	var next ConcurrentMap
+
+	switch mode {
+	case ChannelConcurrentMapKind:
+		next = NewChannelConcurrentMap(NewDefaultBasicMap())
+
+	case LockConcurrentMapKind:
+		next = NewLockConcurrentMap(NewDefaultBasicMap())
+
+	case ShardedConcurrentMapKind:
+		next = NewShardedConcurrentMap(ShardedConcurrentMapParams{ShardCount: am.shardCount})
+	}
+
+	am.current.readStorage(func(storage Map) {
+		for _, entry := range entriesOf(storage) {
+			next.Set(entry.Key, entry.Value)
+		}
+	})
+
+	if ccm, ok := am.current.(ChannelConcurrentMap); ok {
+		ccm.Close()
+	}
+
+	am.current = next.(storageAccessor)
+	am.mode = mode
+}
+
+func (am *adaptiveMap) readStorage(fn func(storage Map)) {
+	defer am.enter()()
+	am.current.readStorage(fn)
+}
+
+func (am *adaptiveMap) writeStorage(fn func(storage Map)) {
+	defer am.enter()()
+	am.current.writeStorage(fn)
+}
+
+func (am *adaptiveMap) writeKeyStorage(key interface{}, fn func(storage Map)) {
+	defer am.enter()()
+	am.current.writeKeyStorage(key, fn)
+}
+
+func (am *adaptiveMap) String() string {
+	defer am.enter()()
+	return fmt.Sprint(am.current)
+}
+
+func (am *adaptiveMap) Clear() {
+	defer am.enter()()
+	am.current.Clear()
+}
+
+func (am *adaptiveMap) Contains(key interface{}) bool {
+	defer am.enter()()
+	return am.current.Contains(key)
+}
+
+func (am *adaptiveMap) Delete(key interface{}) (interface{}, bool) {
+	defer am.enter()()
+	return am.current.Delete(key)
+}
+
+func (am *adaptiveMap) Get(key interface{}) (interface{}, bool) {
+	defer am.enter()()
+	return am.current.Get(key)
+}
+
+func (am *adaptiveMap) Length() int {
+	defer am.enter()()
+	return am.current.Length()
+}
+
+func (am *adaptiveMap) Keys() []interface{} {
+	defer am.enter()()
+	return am.current.Keys()
+}
+
+func (am *adaptiveMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	defer am.enter()()
+	return am.current.Set(key, value)
+}
+
+func (am *adaptiveMap) Close() {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	if ccm, ok := am.current.(ChannelConcurrentMap); ok && !am.closed {
+		ccm.Close()
+	}
+
+	am.closed = true
+}
+
+func (am *adaptiveMap) Mode() MapKind {
+	am.mutex.RLock()
+	defer am.mutex.RUnlock()
+	return am.mode
+}
+
+func (am *adaptiveMap) SetConcurrencyMode(mode MapKind) error {
+	switch mode {
+	case ChannelConcurrentMapKind, LockConcurrentMapKind, ShardedConcurrentMapKind:
+
+	default:
+		return ErrUnsupportedMode
+	}
+
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	if am.closed {
+		return ErrMapClosed
+	}
+
+	am.pinned = true
+	am.switchTo(mode)
+	return nil
+}
+
+// NewAdaptiveMap returns a new AdaptiveMap backed by BasicMap storage.
+func NewAdaptiveMap(params AdaptiveMapParams) AdaptiveMap {
+	threshold := params.ContentionThreshold
+	shardCount := params.ShardCount
+
+	if threshold == 0 {
+		threshold = shardingConcurrency
+	}
+
+	if shardCount == 0 {
+		shardCount = threshold
+	}
+
+	am := &adaptiveMap{
+		current:    NewChannelConcurrentMap(NewDefaultBasicMap()).(storageAccessor),
+		mode:       ChannelConcurrentMapKind,
+		shardCount: shardCount,
+		threshold:  int32(threshold),
+	}
+
+	am.concurrentOps = &concurrentOps{accessor: am}
+	return am
+}
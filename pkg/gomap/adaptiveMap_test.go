@@ -0,0 +1,145 @@
+package gomap
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveMapConcurrentMapOps(t *testing.T) {
+	t.Parallel()
+
+	testConcurrentMapAllOps(t, func() ConcurrentMap {
+		return NewAdaptiveMap(AdaptiveMapParams{ContentionThreshold: 2})
+	})
+}
+
+func TestAdaptiveMapLowContention(t *testing.T) {
+	/// Setup
+	am := NewAdaptiveMap(AdaptiveMapParams{ContentionThreshold: 2})
+	defer am.Close()
+
+	/// When
+	for i := 0; i < adaptivePromoteAfter*10; i++ {
+		am.Set(i, i)
+		am.Get(i)
+	}
+
+	/// Then
+	if mode := am.Mode(); mode != ChannelConcurrentMapKind {
+		t.Errorf("Should stay single-loop without contention, but got %v", mode)
+	}
+}
+
+func TestAdaptiveMapPromotion(t *testing.T) {
+	/// Setup
+	am := NewAdaptiveMap(AdaptiveMapParams{ContentionThreshold: 2})
+	defer am.Close()
+	keyCount := 1000
+
+	for i := 0; i < keyCount; i++ {
+		am.Set(i, i)
+	}
+
+	goroutineCount := 16
+	deadline := time.Now().Add(5 * time.Second)
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(goroutineCount)
+
+	/// When
+	for i := 0; i < goroutineCount; i++ {
+		go func(offset int) {
+			defer waitGroup.Done()
+
+			for j := offset; am.Mode() != ShardedConcurrentMapKind && time.Now().Before(deadline); j++ {
+				key := j % keyCount
+				am.Set(key, key)
+				am.Get(key)
+			}
+		}(i)
+	}
+
+	waitGroup.Wait()
+
+	/// Then
+	if mode := am.Mode(); mode != ShardedConcurrentMapKind {
+		t.Fatalf("Should promote under contention, but got %v", mode)
+	}
+
+	if am.Length() != keyCount {
+		t.Errorf("Should migrate all entries, but got %d", am.Length())
+	}
+
+	for i := 0; i < keyCount; i++ {
+		if value, _ := am.Get(i); value != i {
+			t.Errorf("Should preserve %d after migration, but got %v", i, value)
+		}
+	}
+}
+
+func TestAdaptiveMapSetConcurrencyMode(t *testing.T) {
+	/// Setup
+	am := NewAdaptiveMap(AdaptiveMapParams{})
+	defer am.Close()
+	goroutineCount := 8
+	incrementCount := 500
+	modes := []MapKind{LockConcurrentMapKind, ShardedConcurrentMapKind, ChannelConcurrentMapKind}
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(goroutineCount)
+
+	/// When
+	for i := 0; i < goroutineCount; i++ {
+		go func(key int) {
+			defer waitGroup.Done()
+
+			for j := 0; j < incrementCount; j++ {
+				am.Set(key*incrementCount+j, j)
+
+				am.GetModifySet("counter", func(value interface{}, found bool) interface{} {
+					if !found {
+						return 1
+					}
+
+					return value.(int) + 1
+				})
+			}
+		}(i)
+	}
+
+	for i := 0; i < 30; i++ {
+		if err := am.SetConcurrencyMode(modes[i%len(modes)]); err != nil {
+			t.Fatalf("Should switch mode, but got %v", err)
+		}
+	}
+
+	waitGroup.Wait()
+
+	/// Then
+	if mode := am.Mode(); mode != ChannelConcurrentMapKind {
+		t.Errorf("Should end in the last mode set, but got %v", mode)
+	}
+
+	if value, _ := am.Get("counter"); value != goroutineCount*incrementCount {
+		t.Errorf("Should not lose any increments, but got %v", value)
+	}
+
+	if length := am.Length(); length != goroutineCount*incrementCount+1 {
+		t.Errorf("Should not lose any entries, but got %d", length)
+	}
+}
+
+func TestAdaptiveMapSetConcurrencyModeErrors(t *testing.T) {
+	/// Setup
+	am := NewAdaptiveMap(AdaptiveMapParams{})
+
+	/// When & Then
+	if err := am.SetConcurrencyMode(BasicMapKind); err != ErrUnsupportedMode {
+		t.Errorf("Should reject unsupported mode, but got %v", err)
+	}
+
+	am.Close()
+
+	if err := am.SetConcurrencyMode(LockConcurrentMapKind); err != ErrMapClosed {
+		t.Errorf("Should reject closed map, but got %v", err)
+	}
+}
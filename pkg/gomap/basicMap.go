@@ -1,9 +1,5 @@
 package gomap
 
-import (
-	"fmt"
-)
-
 type basicMap struct {
 	BasicMapParams
 	storage map[interface{}]interface{}
@@ -14,8 +10,10 @@ type BasicMapParams struct {
 	InitialCap uint
 }
 
+// The output has the form "map[k1:v1 k2:v2]", with entries sorted by their
+// formatted keys so that it is stable across calls.
 func (b *basicMap) String() string {
-	return fmt.Sprint(b.storage)
+	return formatEntries(entriesOf(b))
 }
 
 func (b *basicMap) Clear() {
@@ -30,9 +28,9 @@ func (b *basicMap) Contains(key interface{}) bool {
 }
 
 func (b *basicMap) Delete(key interface{}) (interface{}, bool) {
-	prev := b.storage[key]
+	prev, found := b.storage[key]
 	delete(b.storage, key)
-	return prev, prev != nil
+	return prev, found
 }
 
 func (b *basicMap) Get(key interface{}) (interface{}, bool) {
@@ -55,9 +53,9 @@ func (b *basicMap) Keys() []interface{} {
 }
 
 func (b *basicMap) Set(key interface{}, value interface{}) (interface{}, bool) {
-	prev := b.storage[key]
+	prev, found := b.storage[key]
 	b.storage[key] = value
-	return prev, prev != nil
+	return prev, found
 }
 
 // NewBasicMap creates a new BasicMap.
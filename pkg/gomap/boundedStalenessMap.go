@@ -0,0 +1,136 @@
+package gomap
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BoundedStalenessMap represents a Map that serves reads from a local snapshot
+// of a source Map, refreshed periodically. Reads never touch the source, so
+// they do not contend on its lock or loop goroutine, but they may be up to one
+// refresh interval old. Writes go to the source directly and become visible to
+// reads after the next refresh.
+type BoundedStalenessMap interface {
+	Map
+
+	// Refresh replaces the snapshot with the current contents of the source
+	// immediately.
+	Refresh()
+
+	// Stop cancels periodic refreshes. Reads keep serving the last snapshot.
+	Stop()
+}
+
+type stalenessSnapshot struct {
+	entries map[interface{}]interface{}
+	keys    []interface{}
+}
+
+type boundedStalenessMap struct {
+	source       Map
+	interval     time.Duration
+	clock        clock
+	snapshot     atomic.Value
+	mutex        sync.Mutex
+	refreshTimer timer
+	stopped      bool
+}
+
+func (bsm *boundedStalenessMap) current() *stalenessSnapshot {
+	return bsm.snapshot.Load().(*stalenessSnapshot)
+}
+
+func (bsm *boundedStalenessMap) String() string {
+	snapshot := bsm.current()
+	entries := make([]Entry, 0, len(snapshot.keys))
+
+	for _, key := range snapshot.keys {
+		entries = append(entries, Entry{Key: key, Value: snapshot.entries[key]})
+	}
+
+	return formatEntries(entries)
+}
+
+func (bsm *boundedStalenessMap) Clear() {
+	bsm.source.Clear()
+}
+
+func (bsm *boundedStalenessMap) Contains(key interface{}) bool {
+	_, found := bsm.current().entries[key]
+	return found
+}
+
+func (bsm *boundedStalenessMap) Delete(key interface{}) (interface{}, bool) {
+	return bsm.source.Delete(key)
+}
+
+func (bsm *boundedStalenessMap) Get(key interface{}) (interface{}, bool) {
+	value, found := bsm.current().entries[key]
+	return value, found
+}
+
+func (bsm *boundedStalenessMap) Length() int {
+	return len(bsm.current().keys)
+}
+
+func (bsm *boundedStalenessMap) Keys() []interface{} {
+	keys := bsm.current().keys
+	return append(make([]interface{}, 0, len(keys)), keys...)
+}
+
+func (bsm *boundedStalenessMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	return bsm.source.Set(key, value)
+}
+
+func (bsm *boundedStalenessMap) Refresh() {
+	entries := snapshotOf(bsm.source)
+	snapshot := &stalenessSnapshot{
+		entries: make(map[interface{}]interface{}, len(entries)),
+		keys:    make([]interface{}, 0, len(entries)),
+	}
+
+	for _, entry := range entries {
+		snapshot.entries[entry.Key] = entry.Value
+		snapshot.keys = append(snapshot.keys, entry.Key)
+	}
+
+	bsm.snapshot.Store(snapshot)
+}
+
+// Each tick schedules the next one, so that a slow refresh never overlaps with
+// the following one.
+func (bsm *boundedStalenessMap) tick() {
+	bsm.Refresh()
+	bsm.mutex.Lock()
+	defer bsm.mutex.Unlock()
+
+	if !bsm.stopped {
+		bsm.refreshTimer = bsm.clock.AfterFunc(bsm.interval, bsm.tick)
+	}
+}
+
+func (bsm *boundedStalenessMap) Stop() {
+	bsm.mutex.Lock()
+	defer bsm.mutex.Unlock()
+	bsm.stopped = true
+	bsm.refreshTimer.Stop()
+}
+
+func newBoundedStalenessMap(source Map, interval time.Duration, clock clock) *boundedStalenessMap {
+	bsm := &boundedStalenessMap{source: source, interval: interval, clock: clock}
+	bsm.Refresh()
+
+	// tick may fire before AfterFunc returns, and reschedules under the mutex.
+	bsm.mutex.Lock()
+	defer bsm.mutex.Unlock()
+	bsm.refreshTimer = clock.AfterFunc(interval, bsm.tick)
+	return bsm
+}
+
+// NewBoundedStalenessMap returns a new BoundedStalenessMap that takes a snapshot
+// of source every interval. The source must be a ConcurrentMap if it is written
+// to from other goroutines. Call Stop once done to cancel refreshes.
+func NewBoundedStalenessMap(source Map, interval time.Duration) BoundedStalenessMap {
+	return newBoundedStalenessMap(source, interval, systemClock{})
+}
@@ -0,0 +1,94 @@
+package gomap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoundedStalenessMapRefresh(t *testing.T) {
+	/// Setup
+	interval := time.Second
+	fakeClock := newFakeClock()
+	source := NewLockConcurrentMap(NewDefaultBasicMap())
+	source.Set("a", 1)
+	bsm := newBoundedStalenessMap(source, interval, fakeClock)
+	defer bsm.Stop()
+
+	/// When
+	bsm.Set("b", 2)
+	source.Set("a", 10)
+	bsm.Delete("missing")
+
+	/// Then
+	if value, _ := bsm.Get("a"); value != 1 || bsm.Contains("b") || bsm.Length() != 1 {
+		t.Errorf("Should serve stale snapshot before refresh, but got %v", bsm)
+	}
+
+	if !source.Contains("b") {
+		t.Errorf("Should write through to source")
+	}
+
+	fakeClock.Advance(interval - time.Millisecond)
+
+	if bsm.Contains("b") {
+		t.Errorf("Should not refresh before interval elapses")
+	}
+
+	fakeClock.Advance(time.Millisecond)
+
+	if value, _ := bsm.Get("a"); value != 10 || !bsm.Contains("b") || len(bsm.Keys()) != 2 {
+		t.Errorf("Should reflect source after refresh, but got %v", bsm)
+	}
+
+	source.Clear()
+	fakeClock.Advance(interval)
+
+	if bsm.Length() != 0 {
+		t.Errorf("Should keep refreshing every interval")
+	}
+}
+
+func TestBoundedStalenessMapStop(t *testing.T) {
+	/// Setup
+	interval := time.Second
+	fakeClock := newFakeClock()
+	source := NewDefaultBasicMap()
+	bsm := newBoundedStalenessMap(source, interval, fakeClock)
+
+	/// When
+	bsm.Stop()
+	source.Set("a", 1)
+	fakeClock.Advance(interval)
+
+	/// Then
+	if bsm.Contains("a") {
+		t.Errorf("Should not refresh after Stop")
+	}
+
+	bsm.Refresh()
+
+	if !bsm.Contains("a") {
+		t.Errorf("Should refresh on demand")
+	}
+}
+
+// Under -race, this also checks that the first tick does not race with the
+// constructor scheduling it.
+func TestBoundedStalenessMapShortInterval(t *testing.T) {
+	/// Setup
+	source := NewLockConcurrentMap(NewDefaultBasicMap())
+	source.Set("Key", 1)
+
+	/// When & Then
+	for i := 0; i < 100; i++ {
+		bsm := NewBoundedStalenessMap(source, time.Nanosecond)
+
+		if value, _ := bsm.Get("Key"); value != 1 {
+			t.Errorf("Should serve the source's value, but got %v", value)
+		}
+
+		// Let a few ticks run before stopping.
+		time.Sleep(100 * time.Microsecond)
+		bsm.Stop()
+	}
+}
@@ -3,21 +3,129 @@ package gomap
 import (
 	"fmt"
 	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// ChannelConcurrentMap represents a channel-based ConcurrentMap.
+// ChannelConcurrentMap represents a channel-based ConcurrentMap. The Try
+// variants of the mutating methods return ErrMapClosed instead of panicking
+// once the map has been closed, ErrRateLimited instead of blocking if a write
+// rate limit is configured, and ErrLoopUnresponsive if a response timeout is
+// configured and elapses.
 type ChannelConcurrentMap interface {
-	Map
+	ConcurrentMap
+
+	// ClearAndDiscardPending clears the map and drops every Set and Delete that
+	// is waiting for the loop goroutine, so that none of them takes effect after
+	// the reset. Dropped calls return nil and false. Other waiting requests are
+	// processed before the clear, in order, and count towards ProcessedCount;
+	// dropped calls do not. Calls still blocked waiting for a WithMaxInFlight
+	// slot have not been enqueued yet, so they are not dropped and take effect
+	// after the clear.
+	ClearAndDiscardPending()
 	Close()
+
+	// CloseGraceful closes the map like Close, then blocks until the loop
+	// goroutine has processed every request accepted before the close and
+	// exited.
+	CloseGraceful()
+
+	// FullString formats every entry, even if String is limited by
+	// WithStringLimit.
+	FullString() string
+
+	// Ping sends a no-op request through the loop goroutine, and returns
+	// ErrLoopUnresponsive if no response arrives within timeout.
+	Ping(timeout time.Duration) error
+
+	// PrepareClear snapshots the map and returns functions to commit the clear
+	// and to roll it back. The map is untouched until commit is called, and
+	// rollback only has an effect once the clear has been committed. Rollback
+	// never overwrites a write made since the snapshot: it restores the entries
+	// that still had their snapshot values when the clear was committed, and
+	// skips keys that have been set again since.
+	PrepareClear() (commit func(), rollback func())
+
+	// ProcessedCount returns the number of requests handled by the loop
+	// goroutine so far. Compound operations count as a single request.
+	ProcessedCount() uint64
+
+	// Quiesce blocks until every request enqueued before the call has been
+	// processed by the loop goroutine.
+	Quiesce()
+
+	// RecentOps returns the operations recorded by WithOpLog from oldest to
+	// newest, or nil if the option is not set.
+	RecentOps() []OpRecord
+
+	// SwapStorage atomically replaces the backing Map with newStorage on the
+	// loop goroutine and returns the previous one. Requests already queued are
+	// not dropped, and existing entries of newStorage become visible at once.
+	SwapStorage(newStorage Map) Map
+	TryClear() error
+	TryDelete(key interface{}) (interface{}, bool, error)
+	TrySet(key interface{}, value interface{}) (interface{}, bool, error)
+}
+
+// Every request embeds this. The loop goroutine sends the result on replyCh,
+// which is buffered so that replying to a caller that has given up never
+// blocks. The in-flight slot held by the request is released exactly once, by
+// either the loop goroutine or a caller that has stopped waiting.
+type requestBase struct {
+	replyCh  chan requestReply
+	released uint32
+}
+
+// A reply carries either the result of a request, or the value recovered from
+// a panic raised while handling it.
+type requestReply struct {
+	value     interface{}
+	recovered interface{}
+}
+
+func newRequestBase() requestBase {
+	return requestBase{replyCh: make(chan requestReply, 1)}
+}
+
+func (base *requestBase) base() *requestBase {
+	return base
+}
+
+// A second reply, or a reply to a request built without newRequestBase, is
+// dropped.
+func (base *requestBase) reply(reply requestReply) {
+	select {
+	case base.replyCh <- reply:
+	default:
+	}
+}
+
+type loopRequest interface {
+	base() *requestBase
+}
+
+// This runs fn with the storage on the loop goroutine, so that compound
+// operations are atomic.
+type atomicRequest struct {
+	requestBase
+	fn func(storage Map)
 }
 
 type clearRequest struct {
-	doneCh chan<- interface{}
+	requestBase
+}
+
+// The pending requests were taken off the request channel by the caller, and are
+// processed before the clear.
+type clearDiscardRequest struct {
+	requestBase
+	pending []interface{}
 }
 
 type containsRequest struct {
-	key     interface{}
-	foundCh chan<- bool
+	requestBase
+	key interface{}
 }
 
 type deleteResult struct {
@@ -26,8 +134,8 @@ type deleteResult struct {
 }
 
 type deleteRequest struct {
-	key      interface{}
-	resultCh chan<- *deleteResult
+	requestBase
+	key interface{}
 }
 
 type getResult struct {
@@ -36,16 +144,20 @@ type getResult struct {
 }
 
 type getRequest struct {
-	key     interface{}
-	valueCh chan<- *getResult
+	requestBase
+	key interface{}
+}
+
+type pingRequest struct {
+	requestBase
 }
 
 type lenRequest struct {
-	lenCh chan<- int
+	requestBase
 }
 
 type keysRequest struct {
-	keysCh chan<- []interface{}
+	requestBase
 }
 
 type setResult struct {
@@ -54,83 +166,542 @@ type setResult struct {
 }
 
 type setRequest struct {
+	requestBase
 	key   interface{}
 	value interface{}
-	lenCh chan<- *setResult
 }
 
 type stringRequest struct {
-	strCh chan<- string
+	requestBase
 }
 
+// The loop goroutine accesses rawStorage through storage, which is rawStorage
+// wrapped by the decorators that options install.
 type channelConcurrentMap struct {
-	storage   Map
-	requestCh chan interface{}
+	*concurrentOps
+	storage    Map
+	rawStorage Map
+	decorators []func(Map) Map
+	factory    func() Map
+	requestCh  chan interface{}
+	loopDoneCh chan interface{}
+	closed     bool
+	closeMtx   sync.RWMutex
+	batchDrain int
+
+	// Each request holds a slot of inFlight until the loop goroutine has
+	// handled it.
+	inFlight        chan interface{}
+	opLog           *opLog
+	panicHandler    func(recovered interface{})
+	processed       uint64
+	requestBuffer   int
+	responseTimeout time.Duration
+	stringLimit     int
+	validateKeys    bool
+	writeLimiter    *rateLimiter
 }
 
+// Closing an already closed map is a no-op.
 func (ccm *channelConcurrentMap) Close() {
-	close(ccm.requestCh)
+	ccm.closeMtx.Lock()
+	defer ccm.closeMtx.Unlock()
+
+	if !ccm.closed {
+		ccm.closed = true
+		close(ccm.requestCh)
+	}
+}
+
+// Closing the request channel does not discard the requests buffered in it, so
+// waiting for the loop goroutine to exit is enough to drain them.
+func (ccm *channelConcurrentMap) CloseGraceful() {
+	ccm.Close()
+	<-ccm.loopDoneCh
+}
+
+// Send a request to the loop goroutine and wait for its reply, giving up with
+// ErrLoopUnresponsive once the response timeout elapses. The same deadline
+// covers both the send and the reply, so that a loop goroutine that has stopped
+// taking requests cannot block the caller forever. A panic raised while the
+// request was handled is raised again here, on the caller's goroutine, as it
+// would be by the other ConcurrentMap implementations.
+func (ccm *channelConcurrentMap) roundTrip(request loopRequest) (interface{}, error) {
+	timeout, stop := ccm.newResponseTimer()
+	defer stop()
+	return ccm.roundTripWithin(request, timeout)
+}
+
+// A nil timeout channel never fires.
+func (ccm *channelConcurrentMap) roundTripWithin(request loopRequest, timeout <-chan time.Time) (interface{}, error) {
+	if err := ccm.sendWithin(request, timeout); err != nil {
+		return nil, err
+	}
+
+	select {
+	case reply := <-request.base().replyCh:
+		if reply.recovered != nil {
+			panic(reply.recovered)
+		}
+
+		return reply.value, nil
+
+	case <-timeout:
+		ccm.releaseSlot(request)
+		return nil, ErrLoopUnresponsive
+	}
+}
+
+// Send a request and wait for its reply, panicking if either fails. This is used
+// by methods whose signatures do not allow an error to be returned.
+func (ccm *channelConcurrentMap) mustRoundTrip(request loopRequest) interface{} {
+	value, err := ccm.roundTrip(request)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// Send a request, or return ErrMapClosed if the map has been closed. The read
+// lock is held while sending so that Close cannot close the request channel
+// halfway through a send, which is why the send must give up once timeout
+// fires.
+func (ccm *channelConcurrentMap) sendWithin(request loopRequest, timeout <-chan time.Time) error {
+	ccm.closeMtx.RLock()
+	defer ccm.closeMtx.RUnlock()
+
+	if ccm.closed {
+		return ErrMapClosed
+	}
+
+	if ccm.inFlight != nil {
+		select {
+		case ccm.inFlight <- nil:
+
+		case <-timeout:
+			return ErrLoopUnresponsive
+		}
+	}
+
+	select {
+	case ccm.requestCh <- request:
+		return nil
+
+	case <-timeout:
+		ccm.releaseSlot(request)
+		return ErrLoopUnresponsive
+	}
+}
+
+func (ccm *channelConcurrentMap) releaseSlot(request loopRequest) {
+	if ccm.inFlight != nil && atomic.CompareAndSwapUint32(&request.base().released, 0, 1) {
+		<-ccm.inFlight
+	}
+}
+
+// Check that key can be hashed by the storage if key validation is enabled.
+func (ccm *channelConcurrentMap) validateKey(key interface{}) error {
+	if !ccm.validateKeys || key == nil {
+		return nil
+	}
+
+	if keyType := reflect.TypeOf(key); !keyType.Comparable() {
+		return fmt.Errorf("%w: %v is not comparable", ErrInvalidKey, keyType)
+	}
+
+	return nil
+}
+
+func (ccm *channelConcurrentMap) mustValidateKey(key interface{}) {
+	if err := ccm.validateKey(key); err != nil {
+		panic(err)
+	}
+}
+
+// This returns a nil channel, which never fires, if no response timeout is
+// configured.
+func (ccm *channelConcurrentMap) newResponseTimer() (<-chan time.Time, func() bool) {
+	if ccm.responseTimeout <= 0 {
+		return nil, func() bool { return false }
+	}
+
+	timer := time.NewTimer(ccm.responseTimeout)
+	return timer.C, timer.Stop
+}
+
+func (ccm *channelConcurrentMap) readStorage(fn func(storage Map)) {
+	ccm.writeStorage(fn)
+}
+
+// This operation blocks until fn has been run on the loop goroutine.
+func (ccm *channelConcurrentMap) writeStorage(fn func(storage Map)) {
+	ccm.mustRoundTrip(&atomicRequest{requestBase: newRequestBase(), fn: fn})
+}
+
+func (ccm *channelConcurrentMap) writeKeyStorage(key interface{}, fn func(storage Map)) {
+	ccm.writeStorage(fn)
 }
 
 func (ccm *channelConcurrentMap) String() string {
-	strCh := make(chan string, 0)
-	ccm.requestCh <- &stringRequest{strCh: strCh}
-	return <-strCh
+	return ccm.mustRoundTrip(&stringRequest{requestBase: newRequestBase()}).(string)
+}
+
+func (ccm *channelConcurrentMap) FullString() string {
+	var str string
+
+	ccm.readStorage(func(storage Map) {
+		str = formatEntries(entriesOf(storage))
+	})
+
+	return str
 }
 
 // This operation blocks until some result is received.
 func (ccm *channelConcurrentMap) Clear() {
-	requestCh := make(chan interface{}, 0)
-	ccm.requestCh <- &clearRequest{doneCh: requestCh}
-	<-requestCh
+	if err := ccm.TryClear(); err != nil {
+		panic(err)
+	}
+}
+
+// Waiting requests are drained on the calling goroutine, replying to writes
+// directly and handing everything else over to the loop goroutine. Each drained
+// request releases its in-flight slot at once, so that the clear request can
+// always acquire one.
+func (ccm *channelConcurrentMap) ClearAndDiscardPending() {
+	pending := make([]interface{}, 0)
+
+	for drained := false; !drained; {
+		select {
+		case request, ok := <-ccm.requestCh:
+			if !ok {
+				drained = true
+				break
+			}
+
+			switch request := request.(type) {
+			case *setRequest:
+				request.reply(requestReply{value: &setResult{}})
+
+			case *deleteRequest:
+				request.reply(requestReply{value: &deleteResult{}})
+
+			default:
+				pending = append(pending, request)
+			}
+
+			if request, ok := request.(loopRequest); ok {
+				ccm.releaseSlot(request)
+			}
+
+		default:
+			drained = true
+		}
+	}
+
+	ccm.mustRoundTrip(&clearDiscardRequest{requestBase: newRequestBase(), pending: pending})
 }
 
 // This operation blocks until a value is received.
 func (ccm *channelConcurrentMap) Contains(key interface{}) bool {
-	foundCh := make(chan bool, 0)
-	ccm.requestCh <- &containsRequest{key: key, foundCh: foundCh}
-	return <-foundCh
+	ccm.mustValidateKey(key)
+	return ccm.mustRoundTrip(&containsRequest{requestBase: newRequestBase(), key: key}).(bool)
 }
 
 // This operation blocks until some value is received.
 func (ccm *channelConcurrentMap) Delete(key interface{}) (interface{}, bool) {
-	resultCh := make(chan *deleteResult, 0)
-	ccm.requestCh <- &deleteRequest{key: key, resultCh: resultCh}
-	result := <-resultCh
-	return result.prev, result.found
+	prev, found, err := ccm.TryDelete(key)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return prev, found
 }
 
 // This operaton blocks until some value is received.
 func (ccm *channelConcurrentMap) Get(key interface{}) (interface{}, bool) {
-	valueCh := make(chan *getResult, 0)
-	ccm.requestCh <- &getRequest{key: key, valueCh: valueCh}
-	result := <-valueCh
+	ccm.mustValidateKey(key)
+	result := ccm.mustRoundTrip(&getRequest{requestBase: newRequestBase(), key: key}).(*getResult)
 	return result.element, result.found
 }
 
 // This operaton blocks until some value is received.
 func (ccm *channelConcurrentMap) Length() int {
-	requestCh := make(chan int, 0)
-	ccm.requestCh <- &lenRequest{lenCh: requestCh}
-	return <-requestCh
+	return ccm.mustRoundTrip(&lenRequest{requestBase: newRequestBase()}).(int)
 }
 
 // This operation blocks untils keys are received.
 func (ccm *channelConcurrentMap) Keys() []interface{} {
-	keysCh := make(chan []interface{}, 0)
-	ccm.requestCh <- &keysRequest{keysCh: keysCh}
-	return <-keysCh
+	return ccm.mustRoundTrip(&keysRequest{requestBase: newRequestBase()}).([]interface{})
 }
 
 // This operaton blocks until some value is received.
 func (ccm *channelConcurrentMap) Set(key interface{}, value interface{}) (interface{}, bool) {
-	lenCh := make(chan *setResult, 0)
-	ccm.requestCh <- &setRequest{key: key, value: value, lenCh: lenCh}
-	result := <-lenCh
-	return result.element, result.found
+	if ccm.writeLimiter != nil {
+		ccm.writeLimiter.take()
+	}
+
+	prev, found, err := ccm.trySet(key, value)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return prev, found
+}
+
+func (ccm *channelConcurrentMap) Ping(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	_, err := ccm.roundTripWithin(&pingRequest{requestBase: newRequestBase()}, timer.C)
+	return err
+}
+
+func (ccm *channelConcurrentMap) PrepareClear() (commit func(), rollback func()) {
+	var snapshot []Entry
+	ccm.readStorage(func(storage Map) { snapshot = entriesOf(storage) })
+
+	// These are only accessed on the loop goroutine. unchanged holds the entries
+	// of snapshot that had not been changed when the clear was committed.
+	var unchanged []Entry
+	committed := false
+
+	commit = func() {
+		ccm.writeStorage(func(storage Map) {
+			unchanged = unchanged[:0]
+
+			for _, entry := range snapshot {
+				if value, found := storage.Get(entry.Key); found && reflect.DeepEqual(value, entry.Value) {
+					unchanged = append(unchanged, entry)
+				}
+			}
+
+			ccm.clearStorage()
+			committed = true
+		})
+	}
+
+	rollback = func() {
+		ccm.writeStorage(func(storage Map) {
+			if !committed {
+				return
+			}
+
+			for _, entry := range unchanged {
+				if !storage.Contains(entry.Key) {
+					storage.Set(entry.Key, entry.Value)
+				}
+			}
+
+			committed = false
+		})
+	}
+
+	return commit, rollback
+}
+
+func (ccm *channelConcurrentMap) ProcessedCount() uint64 {
+	return atomic.LoadUint64(&ccm.processed)
+}
+
+// Requests are processed in order, so a no-op request drains the queue ahead
+// of it.
+func (ccm *channelConcurrentMap) Quiesce() {
+	ccm.writeStorage(func(storage Map) {})
+}
+
+func (ccm *channelConcurrentMap) RecentOps() []OpRecord {
+	if ccm.opLog == nil {
+		return nil
+	}
+
+	return ccm.opLog.recent()
+}
+
+// The decorators installed by options are not part of the storage, so this
+// describes rawStorage, reading it on the loop goroutine.
+func (ccm *channelConcurrentMap) StorageKind() string {
+	var kind string
+
+	ccm.readStorage(func(storage Map) {
+		kind = storageKindOf(ccm.rawStorage)
+	})
+
+	return kind
+}
+
+func (ccm *channelConcurrentMap) SwapStorage(newStorage Map) Map {
+	var oldStorage Map
+
+	ccm.writeStorage(func(storage Map) {
+		oldStorage = ccm.rawStorage
+		ccm.setStorage(newStorage)
+	})
+
+	return oldStorage
 }
 
+func (ccm *channelConcurrentMap) setStorage(rawStorage Map) {
+	ccm.rawStorage = rawStorage
+	ccm.storage = rawStorage
+
+	for _, decorate := range ccm.decorators {
+		ccm.storage = decorate(ccm.storage)
+	}
+}
+
+// If there is a storage factory, this replaces rawStorage with a fresh one and
+// decorates it again, instead of clearing it in place. This must only be called
+// on the loop goroutine.
+func (ccm *channelConcurrentMap) clearStorage() {
+	if ccm.factory != nil {
+		ccm.setStorage(ccm.factory())
+	} else {
+		ccm.storage.Clear()
+	}
+}
+
+func (ccm *channelConcurrentMap) TryClear() error {
+	_, err := ccm.roundTrip(&clearRequest{requestBase: newRequestBase()})
+	return err
+}
+
+func (ccm *channelConcurrentMap) TryDelete(key interface{}) (interface{}, bool, error) {
+	if err := ccm.validateKey(key); err != nil {
+		return nil, false, err
+	}
+
+	value, err := ccm.roundTrip(&deleteRequest{requestBase: newRequestBase(), key: key})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	result := value.(*deleteResult)
+	return result.prev, result.found, nil
+}
+
+func (ccm *channelConcurrentMap) TrySet(key interface{}, value interface{}) (interface{}, bool, error) {
+	if ccm.writeLimiter != nil && !ccm.writeLimiter.tryTake() {
+		return nil, false, ErrRateLimited
+	}
+
+	return ccm.trySet(key, value)
+}
+
+func (ccm *channelConcurrentMap) trySet(key interface{}, value interface{}) (interface{}, bool, error) {
+	if err := ccm.validateKey(key); err != nil {
+		return nil, false, err
+	}
+
+	reply, err := ccm.roundTrip(&setRequest{requestBase: newRequestBase(), key: key, value: value})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	result := reply.(*setResult)
+	return result.element, result.found, nil
+}
+
+// Handle a single request on the loop goroutine, and return the value to reply
+// with. Unrecognized requests result in an error wrapping ErrUnknownRequest.
+func (ccm *channelConcurrentMap) handleRequest(request interface{}) (interface{}, error) {
+	switch request := request.(type) {
+	case *atomicRequest:
+		request.fn(ccm.storage)
+
+	case *clearRequest:
+		ccm.clearStorage()
+
+	case *clearDiscardRequest:
+		for _, pending := range request.pending {
+			ccm.serve(pending)
+			atomic.AddUint64(&ccm.processed, 1)
+		}
+
+		ccm.clearStorage()
+
+	case *containsRequest:
+		return ccm.storage.Contains(request.key), nil
+
+	case *deleteRequest:
+		prev, found := ccm.storage.Delete(request.key)
+		return &deleteResult{prev: prev, found: found}, nil
+
+	case *getRequest:
+		element, found := ccm.storage.Get(request.key)
+		return &getResult{element: element, found: found}, nil
+
+	case *pingRequest:
+
+	case *lenRequest:
+		return ccm.storage.Length(), nil
+
+	case *keysRequest:
+		return ccm.storage.Keys(), nil
+
+	case *setRequest:
+		element, found := ccm.storage.Set(request.key, request.value)
+		return &setResult{element: element, found: found}, nil
+
+	case *stringRequest:
+		return formatEntriesLimit(entriesOf(ccm.storage), ccm.stringLimit), nil
+
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnknownRequest, reflect.TypeOf(request))
+	}
+
+	return nil, nil
+}
+
+// Handle a request and reply to its sender. A panic raised by the storage or by
+// a callback of the caller is recovered and sent back in the reply instead, so
+// that the loop goroutine keeps serving other requests.
+func (ccm *channelConcurrentMap) serve(request interface{}) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			ccm.handlePanic(recovered)
+
+			if request, ok := request.(loopRequest); ok {
+				request.base().reply(requestReply{recovered: recovered})
+			}
+		}
+	}()
+
+	value, err := ccm.handleRequest(request)
+
+	if err != nil {
+		ccm.handlePanic(err)
+		return
+	}
+
+	if request, ok := request.(loopRequest); ok {
+		request.base().reply(requestReply{value: value})
+	}
+}
+
+// Pass a panic recovered while handling a request to the panic handler, which
+// runs before the caller is replied to. Any panic raised by the handler itself
+// is swallowed, so that it cannot stop the loop goroutine.
+func (ccm *channelConcurrentMap) handlePanic(recovered interface{}) {
+	defer func() {
+		recover()
+	}()
+
+	if ccm.panicHandler != nil {
+		ccm.panicHandler(recovered)
+	}
+}
+
+// Unrecognized requests are dropped instead of crashing the loop goroutine,
+// since there is no way to reply to a sender whose request type is unknown. The
+// error wrapping ErrUnknownRequest is passed to the panic handler instead.
 func (ccm *channelConcurrentMap) loopMap() {
+	defer close(ccm.loopDoneCh)
+
 	for {
 		select {
 		case request, ok := <-ccm.requestCh:
@@ -138,49 +709,72 @@ func (ccm *channelConcurrentMap) loopMap() {
 				return
 			}
 
-			switch request := request.(type) {
-			case *clearRequest:
-				ccm.storage.Clear()
-				request.doneCh <- true
-
-			case *containsRequest:
-				request.foundCh <- ccm.storage.Contains(request.key)
-
-			case *deleteRequest:
-				prev, found := ccm.storage.Delete(request.key)
-				request.resultCh <- &deleteResult{prev: prev, found: found}
+			ccm.process(request)
 
-			case *getRequest:
-				element, found := ccm.storage.Get(request.key)
-				request.valueCh <- &getResult{element: element, found: found}
+			if !ccm.drainBatch() {
+				return
+			}
+		}
+	}
+}
 
-			case *lenRequest:
-				request.lenCh <- ccm.storage.Length()
+func (ccm *channelConcurrentMap) process(request interface{}) {
+	ccm.serve(request)
+	atomic.AddUint64(&ccm.processed, 1)
 
-			case *keysRequest:
-				request.keysCh <- ccm.storage.Keys()
+	if request, ok := request.(loopRequest); ok {
+		ccm.releaseSlot(request)
+	}
+}
 
-			case *setRequest:
-				element, found := ccm.storage.Set(request.key, request.value)
-				request.lenCh <- &setResult{element: element, found: found}
+// Process up to batchDrain requests that are already queued without going back
+// to the select, and report false if the request channel has been closed.
+func (ccm *channelConcurrentMap) drainBatch() bool {
+	for ix := 0; ix < ccm.batchDrain; ix++ {
+		select {
+		case request, ok := <-ccm.requestCh:
+			if !ok {
+				return false
+			}
 
-			case *stringRequest:
-				request.strCh <- fmt.Sprint(ccm.storage)
+			ccm.process(request)
 
-			default:
-				panic(fmt.Sprintf("Unrecognized req type %v", reflect.TypeOf(request)))
-			}
+		default:
+			return true
 		}
 	}
+
+	return true
 }
 
-// NewChannelConcurrentMap returns a ChannelConcurrentMap.
-func NewChannelConcurrentMap(storage Map) ChannelConcurrentMap {
+// The default capacity of the request channel, which WithRequestBuffer changes.
+const defaultRequestBuffer = 1
+
+// NewChannelConcurrentMap returns a ChannelConcurrentMap configured by options,
+// which are applied in order.
+func NewChannelConcurrentMap(storage Map, options ...Option) ChannelConcurrentMap {
 	cm := &channelConcurrentMap{
-		storage:   storage,
-		requestCh: make(chan interface{}, 1),
+		loopDoneCh:    make(chan interface{}),
+		requestBuffer: defaultRequestBuffer,
+	}
+
+	for _, option := range options {
+		option(cm)
 	}
 
+	cm.requestCh = make(chan interface{}, cm.requestBuffer)
+
+	cm.setStorage(storage)
+	cm.concurrentOps = &concurrentOps{accessor: cm}
 	go cm.loopMap()
 	return cm
 }
+
+// NewChannelConcurrentMapWithFactory returns a new ChannelConcurrentMap that
+// creates its storage with factory. Clear, ClearAndDiscardPending and
+// PrepareClear then replace the storage with a new one from factory, wrapped by
+// the same decorators that options installed.
+func NewChannelConcurrentMapWithFactory(factory func() Map, options ...Option) ChannelConcurrentMap {
+	setFactory := func(ccm *channelConcurrentMap) { ccm.factory = factory }
+	return NewChannelConcurrentMap(factory(), append([]Option{setFactory}, options...)...)
+}
@@ -1,52 +1,64 @@
 package gomap
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	gl "github.com/protoman92/gocontainer/pkg/gocollection"
 )
 
-func TestUnsupportedRequestShouldPanic(t *testing.T) {
+func TestUnsupportedRequestShouldReturnError(t *testing.T) {
 	/// Setup
 	bm := NewDefaultBasicMap()
 	requestCh := make(chan interface{}, 1)
 	ccm := &channelConcurrentMap{storage: bm, requestCh: requestCh}
-	var e string
-	var mutex sync.RWMutex
 
-	accessError := func() string {
-		mutex.RLock()
-		defer mutex.RUnlock()
-		return e
-	}
+	/// When
+	_, err := ccm.handleRequest(true)
 
-	setError := func(err interface{}) {
-		mutex.Lock()
-		defer mutex.Unlock()
-		msg := err.(string)
-		e = msg
+	/// Then
+	if !errors.Is(err, ErrUnknownRequest) {
+		t.Errorf("Should have returned ErrUnknownRequest, but got %v", err)
 	}
+}
 
-	go func() {
-		defer func() {
-			if e := recover(); e == nil {
-				t.Errorf("Should have panicked")
-			} else {
-				setError(e)
-			}
-		}()
+func TestUnsupportedRequestShouldNotStopLoop(t *testing.T) {
+	/// Setup
+	bm := NewDefaultBasicMap()
+	requestCh := make(chan interface{}, 1)
+	recoveredCh := make(chan interface{}, 1)
+	ccm := &channelConcurrentMap{
+		storage:      bm,
+		requestCh:    requestCh,
+		loopDoneCh:   make(chan interface{}),
+		panicHandler: func(recovered interface{}) { recoveredCh <- recovered },
+	}
 
-		ccm.loopMap()
-	}()
+	go ccm.loopMap()
+	defer ccm.Close()
 
 	/// When
 	requestCh <- true
 
 	/// Then
-	time.Sleep(time.Millisecond)
+	if _, found, err := ccm.TrySet("Key", "Value"); found || err != nil {
+		t.Errorf("Should still process requests, but got %v", err)
+	}
 
-	if err := accessError(); len(err) == 0 {
-		t.Errorf("Should have panicked")
+	select {
+	case recovered := <-recoveredCh:
+		if err, ok := recovered.(error); !ok || !errors.Is(err, ErrUnknownRequest) {
+			t.Errorf("Should pass ErrUnknownRequest to the panic handler, but got %v", recovered)
+		}
+
+	default:
+		t.Errorf("Should have invoked panic handler for the unknown request")
 	}
 }
 
@@ -64,6 +76,10 @@ func TestChannelConncurrentMapCloseRequest(t *testing.T) {
 	go func() {
 		defer func() {
 			if e := recover(); e != nil {
+				if err, ok := e.(error); !ok || !errors.Is(err, ErrMapClosed) {
+					t.Errorf("Should have panicked with ErrMapClosed, but got %v", e)
+				}
+
 				doneCh <- true
 			}
 		}()
@@ -82,3 +98,759 @@ func TestChannelConncurrentMapCloseRequest(t *testing.T) {
 		t.Errorf("Should have quit loop")
 	}
 }
+
+func TestChannelConcurrentMapClosedErrors(t *testing.T) {
+	/// Setup
+	bm := NewDefaultBasicMap()
+	cm := NewChannelConcurrentMap(bm)
+	key := "Key"
+
+	/// When
+	cm.Close()
+	cm.Close()
+
+	/// Then
+	if err := cm.TryClear(); !errors.Is(err, ErrMapClosed) {
+		t.Errorf("Clear should have returned ErrMapClosed, but got %v", err)
+	}
+
+	if _, _, err := cm.TryDelete(key); !errors.Is(err, ErrMapClosed) {
+		t.Errorf("Delete should have returned ErrMapClosed, but got %v", err)
+	}
+
+	if _, _, err := cm.TrySet(key, "Value"); !errors.Is(err, ErrMapClosed) {
+		t.Errorf("Set should have returned ErrMapClosed, but got %v", err)
+	}
+}
+
+func TestChannelConcurrentMapWriteRateLimit(t *testing.T) {
+	/// Setup
+	limit := 10
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap(), WithWriteRateLimit(limit))
+	defer cm.Close()
+
+	/// When & Then
+	for i := 0; i < limit; i++ {
+		if _, _, err := cm.TrySet(i, i); err != nil {
+			t.Errorf("Burst write %d should succeed, but got %v", i, err)
+		}
+	}
+
+	if _, _, err := cm.TrySet(limit, limit); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Excess write should be rejected, but got %v", err)
+	}
+
+	start := time.Now()
+	cm.Set(limit, limit)
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Excess write should be delayed, but took %v", elapsed)
+	}
+
+	if length := cm.Length(); length != limit+1 {
+		t.Errorf("Should have %d elements, but got %d", limit+1, length)
+	}
+
+	if _, _, err := cm.TryDelete(0); err != nil {
+		t.Errorf("Deletes should not be throttled, but got %v", err)
+	}
+}
+
+type panickingMap struct {
+	Map
+}
+
+func (pm *panickingMap) Get(key interface{}) (interface{}, bool) {
+	panic("Storage failure")
+}
+
+func TestChannelConcurrentMapPing(t *testing.T) {
+	/// Setup
+	healthy := NewChannelConcurrentMap(NewDefaultBasicMap())
+	storage := &wedgedMap{Map: NewDefaultBasicMap(), enteredCh: make(chan interface{}), releaseCh: make(chan interface{})}
+	wedged := NewChannelConcurrentMap(storage)
+	timeout := 50 * time.Millisecond
+	defer healthy.Close()
+	defer wedged.Close()
+	defer close(storage.releaseCh)
+
+	/// When
+	go wedged.Get("Key")
+	<-storage.enteredCh
+
+	/// Then
+	if err := healthy.Ping(timeout); err != nil {
+		t.Errorf("Healthy map should respond, but got %v", err)
+	}
+
+	if err := wedged.Ping(timeout); !errors.Is(err, ErrLoopUnresponsive) {
+		t.Errorf("Wedged map should be unresponsive, but got %v", err)
+	}
+
+	healthy.Close()
+
+	if err := healthy.Ping(timeout); !errors.Is(err, ErrMapClosed) {
+		t.Errorf("Closed map should return ErrMapClosed, but got %v", err)
+	}
+}
+
+func TestChannelConcurrentMapPanicHandler(t *testing.T) {
+	/// Setup
+	recoveredCh := make(chan interface{}, 2)
+
+	handler := func(recovered interface{}) {
+		recoveredCh <- recovered
+		panic("Handler failure")
+	}
+
+	storage := &panickingMap{Map: NewDefaultBasicMap()}
+	cm := NewChannelConcurrentMap(storage, WithPanicHandler(handler))
+	defer cm.Close()
+
+	/// When
+	for i := 0; i < cap(recoveredCh); i++ {
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != "Storage failure" {
+					t.Errorf("Should still panic on the caller's goroutine, but got %v", recovered)
+				}
+			}()
+
+			cm.Get("Key")
+		}()
+	}
+
+	/// Then
+	for i := 0; i < cap(recoveredCh); i++ {
+		select {
+		case recovered := <-recoveredCh:
+			if recovered != "Storage failure" {
+				t.Errorf("Should receive recovered value, but got %v", recovered)
+			}
+
+		default:
+			t.Errorf("Should have invoked panic handler for every panic")
+		}
+	}
+
+	if err := cm.Ping(time.Second); err != nil {
+		t.Errorf("Should keep the loop running after panics, but got %v", err)
+	}
+}
+
+func TestChannelConcurrentMapCallbackPanic(t *testing.T) {
+	/// Setup
+	maps := map[string]ConcurrentMap{
+		"channel": NewChannelConcurrentMap(NewDefaultBasicMap()),
+		"lock":    NewLockConcurrentMap(NewDefaultBasicMap()),
+	}
+
+	defer maps["channel"].(ChannelConcurrentMap).Close()
+
+	for name, cm := range maps {
+		cm.Set("Key", 1)
+
+		/// When
+		recovered := func() (recovered interface{}) {
+			defer func() { recovered = recover() }()
+
+			cm.GetModifySet("Key", func(value interface{}, found bool) interface{} {
+				panic("Callback failure")
+			})
+
+			return nil
+		}()
+
+		/// Then
+		if recovered != "Callback failure" {
+			t.Errorf("%s map should panic on the caller's goroutine, but got %v", name, recovered)
+		}
+
+		if value, _ := cm.Get("Key"); value != 1 {
+			t.Errorf("%s map should keep serving requests, but got %v", name, value)
+		}
+
+		if _, newValue := cm.GetModifySet("Key", func(value interface{}, found bool) interface{} {
+			return value.(int) + 1
+		}); newValue != 2 {
+			t.Errorf("%s map should keep serving compound operations, but got %v", name, newValue)
+		}
+	}
+}
+
+func TestChannelConcurrentMapKeyNormalizer(t *testing.T) {
+	/// Setup
+	lowercase := func(key interface{}) interface{} {
+		if str, ok := key.(string); ok {
+			return strings.ToLower(str)
+		}
+
+		return key
+	}
+
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap(), WithKeyNormalizer(lowercase))
+	defer cm.Close()
+
+	/// When
+	cm.Set("Foo", 1)
+	cm.Set("BAR", 2)
+	cm.Set(3, 3)
+
+	/// Then
+	if value, found := cm.Get("foo"); !found || value != 1 {
+		t.Errorf("Should look up case-insensitively")
+	}
+
+	if prev, found := cm.Set("FOO", 4); !found || prev != 1 {
+		t.Errorf("Should collide with normalized key")
+	}
+
+	if !cm.Contains("bar") || !cm.Contains(3) {
+		t.Errorf("Should contain normalized keys")
+	}
+
+	if _, newValue := cm.GetModifySet("Bar", func(old interface{}, found bool) interface{} {
+		return old.(int) * 10
+	}); newValue != 20 {
+		t.Errorf("Should normalize keys of compound operations, but got %v", newValue)
+	}
+
+	if keys := gl.NewSliceList(cm.Keys()...); keys.Length() != 3 || !keys.ContainsAll("foo", "bar", 3) {
+		t.Errorf("Should return normalized keys, but got %v", keys)
+	}
+
+	if _, found := cm.Delete("Foo"); !found || cm.Length() != 2 {
+		t.Errorf("Should delete normalized key")
+	}
+}
+
+func TestChannelConcurrentMapWithFactory(t *testing.T) {
+	/// Setup
+	storages := make([]Map, 0)
+
+	cm := NewChannelConcurrentMapWithFactory(func() Map {
+		storage := NewDefaultBasicMap()
+		storages = append(storages, storage)
+		return storage
+	}, WithKeyNormalizer(func(key interface{}) interface{} {
+		return strings.ToLower(key.(string))
+	}))
+
+	defer cm.Close()
+	cm.Set("Key", 1)
+
+	/// When
+	cm.Clear()
+	cm.Set("OTHER", 2)
+
+	/// Then
+	if len(storages) != 2 {
+		t.Fatalf("Should create a new storage on Clear, but created %d", len(storages))
+	}
+
+	if storages[0].Length() != 1 {
+		t.Errorf("Should not clear previous storage in place")
+	}
+
+	if value, found := storages[1].Get("other"); !found || value != 2 {
+		t.Errorf("Should decorate fresh storage from factory")
+	}
+
+	if current := cm.SwapStorage(NewDefaultBasicMap()); current != storages[1] {
+		t.Errorf("Should use fresh storage from factory")
+	}
+}
+
+func TestChannelConcurrentMapPrepareClear(t *testing.T) {
+	/// Setup
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap())
+	defer cm.Close()
+	cm.Set(1, 1)
+	cm.Set(2, 2)
+
+	/// When & Then
+	_, abandon := cm.PrepareClear()
+	cm.Set(3, 3)
+	abandon()
+
+	if cm.Length() != 3 {
+		t.Errorf("Should not touch map if clear is never committed")
+	}
+
+	commit, rollback := cm.PrepareClear()
+	commit()
+
+	if !cm.IsEmpty() {
+		t.Errorf("Should clear map on commit")
+	}
+
+	rollback()
+
+	if cm.Length() != 3 || !cm.ContainsAll(1, 2, 3) {
+		t.Errorf("Should restore snapshot on rollback")
+	}
+
+	commit, rollback = cm.PrepareClear()
+	cm.Set(1, 10)
+	commit()
+	cm.Set(2, 20)
+	rollback()
+
+	expected := map[interface{}]interface{}{2: 20, 3: 3}
+
+	if actual := goMapOf(cm); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should only restore entries unchanged since the snapshot, but got %v", actual)
+	}
+}
+
+func TestChannelConcurrentMapQuiesce(t *testing.T) {
+	/// Setup
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap())
+	defer cm.Close()
+	requestCount := 100
+
+	/// When
+	// Enqueue the requests without waiting for their results.
+	for i := 0; i < requestCount; i++ {
+		cm.(*channelConcurrentMap).requestCh <- &setRequest{
+			requestBase: newRequestBase(),
+			key:         i,
+			value:       i,
+		}
+	}
+
+	cm.Quiesce()
+
+	/// Then
+	if length := cm.(*channelConcurrentMap).storage.Length(); length != requestCount {
+		t.Errorf("Should have processed all enqueued requests, but got %d", length)
+	}
+}
+
+func TestChannelConcurrentMapKeyValidation(t *testing.T) {
+	/// Setup
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap(), WithKeyValidation())
+	defer cm.Close()
+
+	/// When
+	_, _, setErr := cm.TrySet([]int{1, 2}, 1)
+	_, _, deleteErr := cm.TryDelete(map[int]int{})
+
+	/// Then
+	if !errors.Is(setErr, ErrInvalidKey) || !strings.Contains(setErr.Error(), "[]int") {
+		t.Errorf("Should return descriptive error for slice key, but got %v", setErr)
+	}
+
+	if !errors.Is(deleteErr, ErrInvalidKey) {
+		t.Errorf("Should return error for map key, but got %v", deleteErr)
+	}
+
+	func() {
+		defer func() {
+			if err, ok := recover().(error); !ok || !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Should panic with ErrInvalidKey on calling goroutine")
+			}
+		}()
+
+		cm.Get([]int{1})
+	}()
+
+	if _, _, err := cm.TrySet(1, 1); err != nil || cm.Ping(time.Second) != nil {
+		t.Errorf("Should keep accepting valid keys")
+	}
+}
+
+func TestChannelConcurrentMapCloseGraceful(t *testing.T) {
+	/// Setup
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap())
+	senderCount := 100
+	accepted := int32(0)
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(senderCount)
+
+	for i := 0; i < senderCount; i++ {
+		go func(i int) {
+			defer waitGroup.Done()
+
+			if _, _, err := cm.TrySet(i, i); err == nil {
+				atomic.AddInt32(&accepted, 1)
+			} else if !errors.Is(err, ErrMapClosed) {
+				t.Errorf("Should only reject writes after close, but got %v", err)
+			}
+		}(i)
+	}
+
+	/// When
+	cm.CloseGraceful()
+	waitGroup.Wait()
+
+	/// Then
+	storage := cm.(*channelConcurrentMap).storage
+
+	if length := storage.Length(); length != int(atomic.LoadInt32(&accepted)) {
+		t.Errorf("Should have processed %d accepted writes, but got %d", accepted, length)
+	}
+
+	// Closing again should return at once instead of blocking.
+	cm.CloseGraceful()
+}
+
+func TestChannelConcurrentMapMaxInFlight(t *testing.T) {
+	/// Setup
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap(), WithMaxInFlight(1))
+	defer cm.Close()
+	ccm := cm.(*channelConcurrentMap)
+	blockCh := make(chan interface{})
+	blockedCh := make(chan interface{})
+	setDoneCh := make(chan interface{})
+
+	go ccm.writeStorage(func(storage Map) {
+		close(blockedCh)
+		<-blockCh
+	})
+
+	<-blockedCh
+
+	/// When
+	go func() {
+		defer close(setDoneCh)
+		cm.Set(1, 1)
+	}()
+
+	/// Then
+	if err := cm.Ping(50 * time.Millisecond); err != ErrLoopUnresponsive {
+		t.Errorf("Should time out waiting for a slot, but got %v", err)
+	}
+
+	if len(ccm.requestCh) != 0 {
+		t.Errorf("Should not enqueue requests beyond the limit")
+	}
+
+	select {
+	case <-setDoneCh:
+		t.Errorf("Should block Set while the limit is saturated")
+
+	default:
+	}
+
+	close(blockCh)
+	<-setDoneCh
+
+	if value, _ := cm.Get(1); value != 1 || cm.Ping(time.Second) != nil {
+		t.Errorf("Should release slots once requests are handled")
+	}
+}
+
+// This blocks the loop goroutine in Get until released, as if it had died.
+type wedgedMap struct {
+	Map
+	enteredCh chan interface{}
+	releaseCh chan interface{}
+}
+
+func (wm *wedgedMap) Get(key interface{}) (interface{}, bool) {
+	close(wm.enteredCh)
+	<-wm.releaseCh
+	return wm.Map.Get(key)
+}
+
+func TestChannelConcurrentMapResponseTimeout(t *testing.T) {
+	/// Setup
+	timeout := 20 * time.Millisecond
+	storage := &wedgedMap{Map: NewDefaultBasicMap(), enteredCh: make(chan interface{}), releaseCh: make(chan interface{})}
+	cm := NewChannelConcurrentMap(storage, WithResponseTimeout(timeout), WithMaxInFlight(2))
+	ccm := cm.(*channelConcurrentMap)
+
+	go func() {
+		defer func() { recover() }()
+		cm.Get("Key")
+	}()
+
+	<-storage.enteredCh
+	doneCh := make(chan interface{})
+
+	/// When
+	go func() {
+		defer close(doneCh)
+
+		// The first write is accepted but never answered, and the second cannot
+		// even be enqueued, since the request buffer is full.
+		if _, _, err := cm.TrySet("Key", "Value"); err != ErrLoopUnresponsive {
+			t.Errorf("Should time out waiting for a reply, but got %v", err)
+		}
+
+		if _, _, err := cm.TryDelete("Key"); err != ErrLoopUnresponsive {
+			t.Errorf("Should time out waiting to send, but got %v", err)
+		}
+
+		func() {
+			defer func() {
+				if err, ok := recover().(error); !ok || err != ErrLoopUnresponsive {
+					t.Errorf("Should panic with ErrLoopUnresponsive, but got %v", err)
+				}
+			}()
+
+			cm.Length()
+		}()
+
+		cm.Close()
+	}()
+
+	/// Then
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatalf("Should return from calls and Close while the loop is stuck")
+	}
+
+	close(storage.releaseCh)
+
+	select {
+	case <-ccm.loopDoneCh:
+	case <-time.After(time.Second):
+		t.Fatalf("Should release every in-flight slot exactly once")
+	}
+
+	if len(ccm.inFlight) != 0 {
+		t.Errorf("Should release slots of abandoned requests, but %d are held", len(ccm.inFlight))
+	}
+}
+
+func TestChannelConcurrentMapOpLog(t *testing.T) {
+	/// Setup
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap(), WithOpLog(4))
+	defer cm.Close()
+
+	/// When
+	cm.Set("a", 1)
+	cm.Get("a")
+	cm.SwapStorage(NewDefaultBasicMap())
+	cm.Delete("a")
+	cm.Set("b", 2)
+	cm.Clear()
+
+	/// Then
+	expected := []OpRecord{{Op: "Get", Key: "a"}, {Op: "Delete", Key: "a"}, {Op: "Set", Key: "b"}, {Op: "Clear"}}
+	records := cm.RecentOps()
+
+	if len(records) != len(expected) {
+		t.Fatalf("Should keep the last %d ops, but got %v", len(expected), records)
+	}
+
+	for ix, record := range records {
+		if record.Op != expected[ix].Op || record.Key != expected[ix].Key {
+			t.Errorf("Should record %v at %d, but got %v", expected[ix], ix, record)
+		}
+
+		if record.Time.IsZero() || (ix > 0 && record.Time.Before(records[ix-1].Time)) {
+			t.Errorf("Should record ops in order with timestamps")
+		}
+	}
+
+	plain := NewChannelConcurrentMap(NewDefaultBasicMap())
+	defer plain.Close()
+
+	if ops := plain.RecentOps(); ops != nil {
+		t.Errorf("Should not record ops without WithOpLog")
+	}
+}
+
+func TestChannelConcurrentMapDryRun(t *testing.T) {
+	/// Setup
+	storage := NewDefaultBasicMap()
+	storage.Set("existing", 1)
+	cm := NewChannelConcurrentMap(storage, WithDryRun(), WithKeyValidation())
+	defer cm.Close()
+
+	/// When
+	setPrev, setFound := cm.Set("existing", 2)
+	newPrev, newFound := cm.Set("new", 3)
+	deletedPrev, deletedFound := cm.Delete("existing")
+	cm.Clear()
+	_, _, err := cm.TrySet([]int{1}, 4)
+
+	/// Then
+	if setPrev != 1 || !setFound {
+		t.Errorf("Should return would-be previous value, but got %v, %t", setPrev, setFound)
+	}
+
+	if newPrev != nil || newFound {
+		t.Errorf("Should report absent key as not found, but got %v, %t", newPrev, newFound)
+	}
+
+	if deletedPrev != 1 || !deletedFound {
+		t.Errorf("Should return would-be deleted value, but got %v, %t", deletedPrev, deletedFound)
+	}
+
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Should still validate keys, but got %v", err)
+	}
+
+	if value, _ := storage.Get("existing"); value != 1 || storage.Length() != 1 {
+		t.Errorf("Should leave the storage unchanged, but got %v", storage)
+	}
+}
+
+func TestChannelConcurrentMapClearAndDiscardPending(t *testing.T) {
+	/// Setup
+	storage := &countingMap{Map: NewDefaultBasicMap()}
+	storage.Set("existing", 0)
+	cm := NewChannelConcurrentMap(storage)
+	defer cm.Close()
+	ccm := cm.(*channelConcurrentMap)
+	started, release := make(chan interface{}), make(chan interface{})
+	go ccm.writeStorage(func(storage Map) { close(started); <-release })
+	<-started
+	writeCount := 3
+	writesDone := int32(0)
+
+	for i := 0; i < writeCount; i++ {
+		go func(key int) {
+			if prev, found := cm.Set(key, key); prev == nil && !found {
+				atomic.AddInt32(&writesDone, 1)
+			}
+		}(i)
+	}
+
+	waitUntil(t, func() bool { return len(ccm.requestCh) == cap(ccm.requestCh) })
+	time.Sleep(20 * time.Millisecond)
+	resetDone := make(chan interface{})
+
+	/// When
+	go func() {
+		defer close(resetDone)
+		cm.ClearAndDiscardPending()
+	}()
+
+	waitUntil(t, func() bool { return atomic.LoadInt32(&writesDone) == int32(writeCount) })
+	close(release)
+	<-resetDone
+
+	/// Then
+	if storage.sets != 1 {
+		t.Errorf("Should not apply any queued write, but got %d sets", storage.sets)
+	}
+
+	if length := cm.Length(); length != 0 {
+		t.Errorf("Should clear the map, but got %d entries", length)
+	}
+}
+
+func TestChannelConcurrentMapProcessedCount(t *testing.T) {
+	/// Setup
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap())
+	defer cm.Close()
+
+	/// When
+	for i := 0; i < 10; i++ {
+		cm.Set(i, i)
+		cm.Get(i)
+	}
+
+	cm.GetModifySet(0, func(value interface{}, found bool) interface{} { return 1 })
+
+	/// Then
+	if count := cm.ProcessedCount(); count != 21 {
+		t.Errorf("Should count every request, but got %d", count)
+	}
+}
+
+func TestChannelConcurrentMapProcessedCountClearAndDiscardPending(t *testing.T) {
+	/// Setup
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap())
+	defer cm.Close()
+	ccm := cm.(*channelConcurrentMap)
+	started, release := make(chan interface{}), make(chan interface{})
+	go ccm.writeStorage(func(storage Map) { close(started); <-release })
+	<-started
+	getDone := make(chan interface{})
+
+	go func() {
+		defer close(getDone)
+		cm.Get("Key")
+	}()
+
+	waitUntil(t, func() bool { return len(ccm.requestCh) == 1 })
+	resetDone := make(chan interface{})
+
+	/// When
+	go func() {
+		defer close(resetDone)
+		cm.ClearAndDiscardPending()
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-resetDone
+	<-getDone
+
+	/// Then
+	if count := cm.ProcessedCount(); count != 3 {
+		t.Errorf("Should count requests processed before the clear, but got %d", count)
+	}
+}
+
+func TestChannelConcurrentMapBatchDrain(t *testing.T) {
+	t.Parallel()
+
+	testConcurrentMapAllOps(t, func() ConcurrentMap {
+		return NewChannelConcurrentMap(NewDefaultBasicMap(), WithBatchDrain(8))
+	})
+}
+
+// Saturate the loop goroutine with Sets from parallel producers.
+func benchmarkChannelConcurrentMapSaturated(b *testing.B, options ...Option) {
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap(), options...)
+	defer cm.Close()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			cm.Set(i%1024, i)
+		}
+	})
+}
+
+func BenchmarkChannelConcurrentMapUnbatched(b *testing.B) {
+	benchmarkChannelConcurrentMapSaturated(b)
+}
+
+func BenchmarkChannelConcurrentMapBatchDrain(b *testing.B) {
+	benchmarkChannelConcurrentMapSaturated(b, WithBatchDrain(16))
+}
+
+func TestChannelConcurrentMapOptions(t *testing.T) {
+	/// Setup
+	defaults := NewChannelConcurrentMap(NewDefaultBasicMap()).(*channelConcurrentMap)
+	defer defaults.Close()
+
+	/// When
+	configured := NewChannelConcurrentMap(
+		NewDefaultBasicMap(),
+		WithRequestBuffer(8),
+		WithStringLimit(1),
+		WithOpLog(2),
+		WithBatchDrain(4),
+	).(*channelConcurrentMap)
+
+	defer configured.Close()
+	configured.Set("a", 1)
+	configured.Set("b", 2)
+
+	/// Then
+	if cap(defaults.requestCh) != defaultRequestBuffer || defaults.stringLimit != 0 || defaults.opLog != nil || defaults.batchDrain != 0 {
+		t.Errorf("Should apply defaults without options")
+	}
+
+	if cap(configured.requestCh) != 8 || configured.batchDrain != 4 {
+		t.Errorf("Should apply every option")
+	}
+
+	if str := fmt.Sprint(configured); !strings.Contains(str, "1 more") {
+		t.Errorf("Should limit String alongside other options, but got %s", str)
+	}
+
+	if ops := configured.RecentOps(); len(ops) != 2 {
+		t.Errorf("Should record ops alongside other options, but got %v", ops)
+	}
+}
@@ -0,0 +1,26 @@
+package gomap
+
+import (
+	"time"
+)
+
+// This abstracts time so that time-dependent maps can be tested with a fake
+// clock.
+type clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, fn func()) timer
+}
+
+type timer interface {
+	Stop() bool
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, fn func()) timer {
+	return time.AfterFunc(d, fn)
+}
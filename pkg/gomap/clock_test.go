@@ -0,0 +1,76 @@
+package gomap
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type fakeTimer struct {
+	clock   *fakeClock
+	when    time.Time
+	fn      func()
+	stopped bool
+}
+
+func (ft *fakeTimer) Stop() bool {
+	ft.clock.mutex.Lock()
+	defer ft.clock.mutex.Unlock()
+	wasActive := !ft.stopped
+	ft.stopped = true
+	return wasActive
+}
+
+// Timers only fire when Advance is called, on the calling goroutine.
+type fakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func (fc *fakeClock) Now() time.Time {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return fc.now
+}
+
+func (fc *fakeClock) AfterFunc(d time.Duration, fn func()) timer {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	ft := &fakeTimer{clock: fc, when: fc.now.Add(d), fn: fn}
+	fc.timers = append(fc.timers, ft)
+	return ft
+}
+
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.mutex.Lock()
+	fc.now = fc.now.Add(d)
+	due := make([]*fakeTimer, 0)
+	remaining := make([]*fakeTimer, 0)
+
+	for _, ft := range fc.timers {
+		if ft.stopped {
+			continue
+		} else if !ft.when.After(fc.now) {
+			ft.stopped = true
+			due = append(due, ft)
+		} else {
+			remaining = append(remaining, ft)
+		}
+	}
+
+	fc.timers = remaining
+	fc.mutex.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].when.Before(due[j].when)
+	})
+
+	for _, ft := range due {
+		ft.fn()
+	}
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
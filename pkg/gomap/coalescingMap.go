@@ -0,0 +1,166 @@
+package gomap
+
+import (
+	"sync"
+	"time"
+)
+
+// CoalescingMap represents a Map that buffers Set operations and writes only the
+// last value for each key to its storage.
+type CoalescingMap interface {
+	Map
+
+	// Flush writes all buffered values to the storage immediately.
+	Flush()
+
+	// Stop flushes buffered values and cancels any pending flush. Subsequent Set
+	// operations are written through to the storage directly.
+	Stop()
+}
+
+type coalescingMap struct {
+	mutex      sync.Mutex
+	storage    Map
+	window     time.Duration
+	clock      clock
+	pending    map[interface{}]interface{}
+	flushTimer timer
+	stopped    bool
+}
+
+func (cm *coalescingMap) String() string {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	entries := make([]Entry, 0)
+
+	for _, key := range cm.keys() {
+		value, _ := cm.get(key)
+		entries = append(entries, Entry{Key: key, Value: value})
+	}
+
+	return formatEntries(entries)
+}
+
+func (cm *coalescingMap) Clear() {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.pending = make(map[interface{}]interface{})
+	cm.storage.Clear()
+}
+
+func (cm *coalescingMap) Contains(key interface{}) bool {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	_, found := cm.get(key)
+	return found
+}
+
+func (cm *coalescingMap) Delete(key interface{}) (interface{}, bool) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	prev, found := cm.get(key)
+	delete(cm.pending, key)
+	cm.storage.Delete(key)
+	return prev, found
+}
+
+func (cm *coalescingMap) Get(key interface{}) (interface{}, bool) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	return cm.get(key)
+}
+
+// Buffered values take precedence over the storage.
+func (cm *coalescingMap) get(key interface{}) (interface{}, bool) {
+	if value, found := cm.pending[key]; found {
+		return value, found
+	}
+
+	return cm.storage.Get(key)
+}
+
+func (cm *coalescingMap) Length() int {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	return len(cm.keys())
+}
+
+func (cm *coalescingMap) Keys() []interface{} {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	return cm.keys()
+}
+
+func (cm *coalescingMap) keys() []interface{} {
+	keys := cm.storage.Keys()
+
+	for key := range cm.pending {
+		if !cm.storage.Contains(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// The first buffered Set within a window schedules a flush at the end of it.
+func (cm *coalescingMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if cm.stopped {
+		return cm.storage.Set(key, value)
+	}
+
+	prev, found := cm.get(key)
+	cm.pending[key] = value
+
+	if cm.flushTimer == nil {
+		cm.flushTimer = cm.clock.AfterFunc(cm.window, cm.Flush)
+	}
+
+	return prev, found
+}
+
+func (cm *coalescingMap) Flush() {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.flush()
+}
+
+func (cm *coalescingMap) flush() {
+	for key, value := range cm.pending {
+		cm.storage.Set(key, value)
+	}
+
+	cm.pending = make(map[interface{}]interface{})
+
+	if cm.flushTimer != nil {
+		cm.flushTimer.Stop()
+		cm.flushTimer = nil
+	}
+}
+
+func (cm *coalescingMap) Stop() {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.flush()
+	cm.stopped = true
+}
+
+func newCoalescingMap(storage Map, window time.Duration, clock clock) *coalescingMap {
+	return &coalescingMap{
+		storage: storage,
+		window:  window,
+		clock:   clock,
+		pending: make(map[interface{}]interface{}),
+	}
+}
+
+// NewCoalescingMap returns a new CoalescingMap that buffers Set operations for
+// window before writing them to storage, so that a key updated many times in
+// quick succession is only written once, with its last value. Reads reflect
+// buffered values immediately. Call Stop once done to flush remaining values.
+func NewCoalescingMap(storage Map, window time.Duration) CoalescingMap {
+	return newCoalescingMap(storage, window, systemClock{})
+}
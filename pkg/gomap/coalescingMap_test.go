@@ -0,0 +1,58 @@
+package gomap
+
+import (
+	"testing"
+	"time"
+)
+
+type countingMap struct {
+	Map
+	sets int
+}
+
+func (cm *countingMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	cm.sets++
+	return cm.Map.Set(key, value)
+}
+
+func TestCoalescingMapCollapsesWrites(t *testing.T) {
+	/// Setup
+	window := time.Second
+	storage := &countingMap{Map: NewDefaultBasicMap()}
+	fakeClock := newFakeClock()
+	cm := newCoalescingMap(storage, window, fakeClock)
+
+	/// When
+	for i := 0; i < 10; i++ {
+		cm.Set("Key", i)
+	}
+
+	/// Then
+	if value, _ := cm.Get("Key"); value != 9 {
+		t.Errorf("Should read buffered value, but got %v", value)
+	}
+
+	if storage.sets != 0 {
+		t.Errorf("Should not write before window ends, but wrote %d times", storage.sets)
+	}
+
+	fakeClock.Advance(window)
+
+	if value, _ := storage.Get("Key"); storage.sets != 1 || value != 9 {
+		t.Errorf("Should flush a single write with last value, but wrote %d times", storage.sets)
+	}
+
+	cm.Set("Key", 10)
+	cm.Set("Other", 11)
+	cm.Stop()
+
+	if storage.sets != 3 || storage.Length() != 2 {
+		t.Errorf("Should flush on Stop, but wrote %d times", storage.sets)
+	}
+
+	cm.Set("Key", 12)
+
+	if value, _ := storage.Get("Key"); value != 12 {
+		t.Errorf("Should write through after Stop, but got %v", value)
+	}
+}
@@ -0,0 +1,50 @@
+package gomap
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+)
+
+// Codec serializes the entries of a Map, e.g. to persist them to a file.
+type Codec interface {
+	Encode(w io.Writer, entries []Entry) error
+	Decode(r io.Reader) ([]Entry, error)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(w io.Writer, entries []Entry) error {
+	return json.NewEncoder(w).Encode(entries)
+}
+
+func (jsonCodec) Decode(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	err := json.NewDecoder(r).Decode(&entries)
+	return entries, err
+}
+
+// NewJSONCodec returns a Codec that encodes entries as a JSON array. Keys and
+// values decode to their JSON types, so e.g. numbers come back as float64.
+func NewJSONCodec() Codec {
+	return jsonCodec{}
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Encode(w io.Writer, entries []Entry) error {
+	return gob.NewEncoder(w).Encode(entries)
+}
+
+func (gobCodec) Decode(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	err := gob.NewDecoder(r).Decode(&entries)
+	return entries, err
+}
+
+// NewGobCodec returns a Codec that encodes entries with encoding/gob, which
+// preserves the types of keys and values. Types other than the predeclared ones
+// must be registered with gob.Register.
+func NewGobCodec() Codec {
+	return gobCodec{}
+}
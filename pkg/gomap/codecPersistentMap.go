@@ -0,0 +1,128 @@
+package gomap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CodecPersistentMap represents a Map whose entries are persisted to a file
+// with a Codec.
+type CodecPersistentMap interface {
+	Map
+
+	// Save writes every entry to the file. The file is replaced atomically, so
+	// that a failed save leaves the previous snapshot intact.
+	Save() error
+
+	// Close saves the map once, the same way as Save. Changes are never saved
+	// automatically, so changes made after Close are only persisted by calling
+	// Save explicitly.
+	Close() error
+}
+
+// The mutex serializes saves, so that they cannot interleave on the
+// temporary file.
+type codecPersistentMap struct {
+	mutex   sync.Mutex
+	storage Map
+	codec   Codec
+	path    string
+}
+
+func (cpm *codecPersistentMap) String() string {
+	return fmt.Sprint(cpm.storage)
+}
+
+func (cpm *codecPersistentMap) Clear() {
+	cpm.storage.Clear()
+}
+
+func (cpm *codecPersistentMap) Contains(key interface{}) bool {
+	return cpm.storage.Contains(key)
+}
+
+func (cpm *codecPersistentMap) Delete(key interface{}) (interface{}, bool) {
+	return cpm.storage.Delete(key)
+}
+
+func (cpm *codecPersistentMap) Get(key interface{}) (interface{}, bool) {
+	return cpm.storage.Get(key)
+}
+
+func (cpm *codecPersistentMap) Length() int {
+	return cpm.storage.Length()
+}
+
+func (cpm *codecPersistentMap) Keys() []interface{} {
+	return cpm.storage.Keys()
+}
+
+func (cpm *codecPersistentMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	return cpm.storage.Set(key, value)
+}
+
+func (cpm *codecPersistentMap) Save() error {
+	cpm.mutex.Lock()
+	defer cpm.mutex.Unlock()
+
+	file, err := os.CreateTemp(filepath.Dir(cpm.path), filepath.Base(cpm.path)+".tmp*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	if err := cpm.codec.Encode(file, snapshotOf(cpm.storage)); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), cpm.path)
+}
+
+func (cpm *codecPersistentMap) Close() error {
+	return cpm.Save()
+}
+
+func (cpm *codecPersistentMap) load() error {
+	file, err := os.Open(cpm.path)
+
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	entries, err := cpm.codec.Decode(file)
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		cpm.storage.Set(entry.Key, entry.Value)
+	}
+
+	return nil
+}
+
+// NewCodecPersistentMap returns a new CodecPersistentMap that stores its
+// entries in storage and persists them to path with codec. Entries saved at
+// path are loaded into storage first; a missing file counts as empty.
+func NewCodecPersistentMap(storage Map, codec Codec, path string) (CodecPersistentMap, error) {
+	cpm := &codecPersistentMap{storage: storage, codec: codec, path: path}
+
+	if err := cpm.load(); err != nil {
+		return nil, err
+	}
+
+	return cpm, nil
+}
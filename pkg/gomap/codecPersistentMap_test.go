@@ -0,0 +1,53 @@
+package gomap
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testCodecPersistentMapRoundTrip(t *testing.T, codec Codec, entries map[interface{}]interface{}) {
+	/// Setup
+	path := filepath.Join(t.TempDir(), "map.snapshot")
+	cpm, err := NewCodecPersistentMap(NewDefaultBasicMap(), codec, path)
+
+	if err != nil {
+		t.Fatalf("Should treat missing file as empty, but got %v", err)
+	}
+
+	for key, value := range entries {
+		cpm.Set(key, value)
+	}
+
+	/// When
+	if err := cpm.Close(); err != nil {
+		t.Fatalf("Should save on Close, but got %v", err)
+	}
+
+	loaded, err := NewCodecPersistentMap(NewDefaultBasicMap(), codec, path)
+
+	/// Then
+	if err != nil {
+		t.Fatalf("Should load saved file, but got %v", err)
+	}
+
+	if actual := goMapOf(loaded); !reflect.DeepEqual(actual, entries) {
+		t.Errorf("Should round-trip %v, but got %v", entries, actual)
+	}
+}
+
+func TestCodecPersistentMapJSON(t *testing.T) {
+	testCodecPersistentMapRoundTrip(t, NewJSONCodec(), map[interface{}]interface{}{
+		"a": "value",
+		"b": 1.5,
+		"c": true,
+	})
+}
+
+func TestCodecPersistentMapGob(t *testing.T) {
+	testCodecPersistentMapRoundTrip(t, NewGobCodec(), map[interface{}]interface{}{
+		"a": "value",
+		1:   int64(2),
+		2.5: []byte("bytes"),
+	})
+}
@@ -0,0 +1,50 @@
+package gomap
+
+import (
+	"reflect"
+)
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// The parts are stored in an array of interface{} with one element per part.
+// Arrays are comparable element by element, and arrays of different lengths
+// have different types, so composite keys are equal exactly when their parts
+// are.
+type compositeKey struct {
+	parts interface{}
+}
+
+// CompositeKey returns a key made of parts, which can be used directly with any
+// Map. Keys built from equal parts in the same order are equal, so different
+// call sites produce identical keys for the same (userID, resourceID) pair.
+// Every part must be comparable.
+func CompositeKey(parts ...interface{}) interface{} {
+	array := reflect.New(reflect.ArrayOf(len(parts), interfaceType)).Elem()
+
+	for ix, part := range parts {
+		if part != nil {
+			array.Index(ix).Set(reflect.ValueOf(part))
+		}
+	}
+
+	return compositeKey{parts: array.Interface()}
+}
+
+// PartsOf returns the parts of a key built by CompositeKey, and false if key is
+// not a composite key.
+func PartsOf(key interface{}) ([]interface{}, bool) {
+	composite, ok := key.(compositeKey)
+
+	if !ok {
+		return nil, false
+	}
+
+	array := reflect.ValueOf(composite.parts)
+	parts := make([]interface{}, array.Len())
+
+	for ix := range parts {
+		parts[ix] = array.Index(ix).Interface()
+	}
+
+	return parts, true
+}
@@ -0,0 +1,57 @@
+package gomap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompositeKeyEquality(t *testing.T) {
+	/// Setup
+	m := NewDefaultBasicMap()
+
+	/// When
+	m.Set(CompositeKey("user", 1), "a")
+
+	/// Then
+	if CompositeKey("user", 1) != CompositeKey("user", 1) {
+		t.Errorf("Should produce equal keys for equal parts")
+	}
+
+	if value, found := m.Get(CompositeKey("user", 1)); !found || value != "a" {
+		t.Errorf("Should be usable with Set and Get")
+	}
+
+	distinct := []interface{}{
+		CompositeKey(1, "user"),
+		CompositeKey("user", 1, nil),
+		CompositeKey("user"),
+		CompositeKey("user", int64(1)),
+	}
+
+	for _, key := range distinct {
+		if m.Contains(key) {
+			t.Errorf("Should distinguish %v from (user, 1)", key)
+		}
+	}
+}
+
+func TestCompositeKeyPartsOf(t *testing.T) {
+	/// Setup
+	parts := []interface{}{"user", 1, nil, 2.5}
+
+	/// When
+	roundTrip, ok := PartsOf(CompositeKey(parts...))
+
+	/// Then
+	if !ok || !reflect.DeepEqual(roundTrip, parts) {
+		t.Errorf("Should round-trip parts, but got %v", roundTrip)
+	}
+
+	if empty, ok := PartsOf(CompositeKey()); !ok || len(empty) != 0 {
+		t.Errorf("Should support keys without parts")
+	}
+
+	if _, ok := PartsOf("user"); ok {
+		t.Errorf("Should reject keys that are not composite")
+	}
+}
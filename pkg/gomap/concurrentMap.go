@@ -0,0 +1,223 @@
+package gomap
+
+import (
+	"math/rand"
+	"reflect"
+)
+
+// ConcurrentMap represents a thread-safe Map. Methods beyond those of Map are
+// performed atomically with respect to other operations on the same map.
+type ConcurrentMap interface {
+	Map
+
+	// AppendToSlice atomically appends values to the []interface{} stored for
+	// key, creating it if key is absent, and returns the new length. A value
+	// that is not a []interface{} becomes the first element of the slice.
+	AppendToSlice(key interface{}, values ...interface{}) int
+
+	// ApplyDelta atomically deletes the keys of removed and then sets the
+	// entries of added, so readers never observe a partially applied delta. It
+	// returns the resulting length. The values of removed are ignored, which
+	// means the results of Diff can be applied directly.
+	ApplyDelta(added, removed map[interface{}]interface{}) int
+
+	// Checksum returns a hash of all entries computed on a consistent snapshot.
+	// The hash does not depend on iteration order, so maps with the same entries
+	// produce the same checksum, while differing maps almost always do not.
+	// Values are hashed by value like keys, with pointers hashed by address, and
+	// values that cannot be compared, such as slices, are hashed by their
+	// formatted representation.
+	Checksum() uint64
+
+	// CompareAndSwapMany atomically applies updates in order, replacing the value
+	// of each key with New if it is present and its current value equals Old, as
+	// per reflect.DeepEqual. If bestEffort is false, either every update applies
+	// or none does; otherwise the matching ones apply and the rest are skipped.
+	// It returns the number of updates applied, and whether every one matched.
+	CompareAndSwapMany(updates []CASUpdate, bestEffort bool) (applied int, allSucceeded bool)
+
+	// ContainsAll reports whether every key is present, checked against a
+	// single consistent view of the map.
+	ContainsAll(keys ...interface{}) bool
+
+	// ContainsAny reports whether at least one key is present, checked against
+	// a single consistent view of the map.
+	ContainsAny(keys ...interface{}) bool
+
+	// ConvertTo returns a new Map of the given kind, built by BuildMap from a
+	// consistent snapshot of this map. This panics if kind is not recognized.
+	// As with BuildMap, a result of ChannelConcurrentMapKind must be
+	// type-asserted to ChannelConcurrentMap and closed, or its loop goroutine
+	// leaks.
+	ConvertTo(kind MapKind) Map
+
+	// CopyTo copies a snapshot of all entries into dst, overwriting existing
+	// values for the same keys and leaving other keys of dst alone. It returns
+	// the number of entries copied.
+	CopyTo(dst map[interface{}]interface{}) int
+
+	// Diff compares this map against other, using snapshots of both. It returns
+	// the entries only in this map (added), the entries only in other (removed),
+	// and the entries of this map whose values differ from other according to
+	// reflect.DeepEqual (changed).
+	Diff(other Map) (added, removed, changed map[interface{}]interface{})
+
+	// EntriesChan streams a snapshot of all entries over a channel with the
+	// given buffer size, and closes the channel once every entry has been sent.
+	EntriesChan(bufferSize int) <-chan Entry
+
+	// EntrySet returns a live view of the entries, which supports value-aware
+	// removal.
+	EntrySet() EntrySet
+
+	// ForEachParallel takes a snapshot of the map and calls fn for every entry
+	// on a pool of workers goroutines, returning once all entries have been
+	// visited. Since fn runs concurrently it must be goroutine-safe, and it must
+	// not call back into the map. workers defaults to the number of CPUs if it
+	// is not positive.
+	ForEachParallel(workers int, fn func(key, value interface{}))
+
+	// GetDeleteIf atomically reads the value for key and deletes it only if
+	// predicate returns true for it, returning the value either way. Absent keys
+	// return nil and false without calling predicate, which must not call back
+	// into the map.
+	GetDeleteIf(key interface{}, predicate func(value interface{}) bool) (value interface{}, deleted bool)
+
+	// GetIfPresent returns the value for key, or nil if key is absent. Since a
+	// key may be stored with a nil value, use TryGet to tell the two apart.
+	GetIfPresent(key interface{}) interface{}
+
+	// GetModifySet atomically reads the value for key, transforms it with modify
+	// and stores the result, returning both the old and new values. modify
+	// receives whether key was present, and must not call back into the map.
+	GetModifySet(key interface{}, modify func(oldValue interface{}, found bool) interface{}) (interface{}, interface{})
+
+	// GetOrElse returns the value for key, or the result of fallbackFn if key is
+	// absent. Unlike LoadOrStore, the fallback value is not stored, and
+	// fallbackFn only runs on a miss.
+	GetOrElse(key interface{}, fallbackFn func() interface{}) interface{}
+
+	// IncrementBounded atomically adds delta to the int64 stored for key, but
+	// stores max instead if the sum would exceed it, and reports whether it did.
+	// A missing key, or a value that is not an int64, counts as 0. A sum that
+	// overflows int64 is capped, and one below math.MinInt64 stores
+	// math.MinInt64.
+	IncrementBounded(key interface{}, delta, max int64) (newValue int64, capped bool)
+
+	// Invert returns a new BasicMap with the keys and values of a snapshot of
+	// this map swapped. It returns an error wrapping ErrDuplicateValue if two
+	// keys share a value, or ErrInvalidKey if a value is not comparable.
+	Invert() (Map, error)
+
+	// IsEmpty reports whether the map has no entries.
+	IsEmpty() bool
+
+	// KeysWhere returns the keys that satisfy predicate, evaluated over a single
+	// consistent view of the map. predicate must not call back into the map.
+	KeysWhere(predicate func(key interface{}) bool) []interface{}
+
+	// KeysWithPrefix returns the string keys that begin with prefix. Keys that
+	// are not strings are ignored.
+	KeysWithPrefix(prefix string) []interface{}
+
+	// LoadOrStore returns the value for key if it is present. Otherwise it
+	// stores and returns the result of valueFn, which runs at most once for
+	// concurrent callers that miss the same key, and without blocking other
+	// operations on the map. loaded reports whether the value was not computed
+	// by this call.
+	LoadOrStore(key interface{}, valueFn func() interface{}) (actual interface{}, loaded bool)
+
+	// LockKey acquires an advisory lock for key and returns a function that
+	// releases it. The lock only excludes other LockKey callers, and does not
+	// block regular operations on the map, so that callers can run compound
+	// sequences of map calls without other lockers interleaving.
+	LockKey(key interface{}) func()
+
+	// MergeFrom atomically folds a snapshot of other into this map. For keys
+	// present in both, the stored value becomes the result of combine, which
+	// must not call back into either map.
+	MergeFrom(other Map, combine func(key, existing, incoming interface{}) interface{})
+
+	// PopMany atomically deletes every present key in keys, and returns their
+	// values keyed by key. Absent keys are left out of the result.
+	PopMany(keys []interface{}) map[interface{}]interface{}
+
+	// RotateValue atomically replaces the value for key with the option that
+	// follows it in options, wrapping around, and returns the new value. If key
+	// is absent or its value is not one of options, options[0] is stored. If
+	// options is empty, this returns nil and leaves the map untouched.
+	RotateValue(key interface{}, options []interface{}) interface{}
+
+	// Sample returns up to k entries chosen uniformly at random from a snapshot
+	// of the map, using rng as the source of randomness. Seeding rng makes the
+	// sample deterministic for the same contents. A time-seeded source is used if
+	// rng is nil, and every entry is returned if k exceeds the length.
+	Sample(k int, rng *rand.Rand) []Entry
+
+	// ScopeByPrefix returns a live view of the entries whose keys begin with
+	// prefix. Keys passed to the view are formatted as strings and prefixed
+	// before reaching this map, and Keys on the view returns them with the
+	// prefix stripped. The prefix should include any separator, e.g. "user:".
+	ScopeByPrefix(prefix string) Map
+
+	// SetAndThen sets key to value, then calls after exactly once with the
+	// previous value and whether key was present. after runs on the calling
+	// goroutine once the write is visible to other callers, so it may call back
+	// into the map.
+	SetAndThen(key interface{}, value interface{}, after func(prev interface{}, existed bool))
+
+	// SetIfAbsent sets key to value only if key is absent, and returns the
+	// existing value and true otherwise.
+	SetIfAbsent(key interface{}, value interface{}) (interface{}, bool)
+
+	// SetIfChanged atomically sets key to value unless key is already present
+	// with a value that is reflect.DeepEqual to it, and reports whether it
+	// wrote.
+	SetIfChanged(key interface{}, value interface{}) bool
+
+	// StorageKind describes the concrete type of the storage backing the map,
+	// e.g. "BasicMap", without exposing the storage itself. Kinds that BuildMap
+	// can construct are named by MapKind.String, and other types by their Go
+	// type.
+	StorageKind() string
+
+	// TakeSlice atomically returns the []interface{} stored for key and deletes
+	// key, so that values appended with AppendToSlice are each taken once. This
+	// returns false if key is absent, and leaves key alone if its value is not a
+	// []interface{}.
+	TakeSlice(key interface{}) ([]interface{}, bool)
+
+	// TopN returns the n entries with the largest values according to less,
+	// ordered from largest to smallest. It keeps a bounded heap of n entries,
+	// and returns every entry if n exceeds the length of the map. less must not
+	// call back into the map.
+	TopN(n int, less func(a, b interface{}) bool) []Entry
+
+	// TryGet returns the value for key and whether key is present, even if its
+	// value is nil.
+	TryGet(key interface{}) (interface{}, bool)
+
+	// UpdateValuesOfType atomically replaces every value whose dynamic type is
+	// exactly t with the result of mutate, and returns the number of entries
+	// updated. nil values never match. mutate may not call back into the map.
+	UpdateValuesOfType(t reflect.Type, mutate func(value interface{}) interface{}) int
+
+	// UpdateWhere atomically replaces the value of every entry matching
+	// predicate with the result of mutate, and returns the number of entries
+	// updated. Neither function may call back into the map.
+	UpdateWhere(predicate func(key, value interface{}) bool, mutate func(key, value interface{}) interface{}) int
+
+	// Verify checks the internal invariants of the map, e.g. that Length matches
+	// the number of keys and that every key can be looked up, and returns an
+	// error wrapping ErrInvariantViolated for the first violation found. This is
+	// meant for tests and debugging.
+	Verify() error
+}
+
+// CASUpdate is a single compare-and-swap for ConcurrentMap.CompareAndSwapMany,
+// which replaces the value of Key with New if it currently equals Old.
+type CASUpdate struct {
+	Key interface{}
+	Old interface{}
+	New interface{}
+}
@@ -0,0 +1,694 @@
+package gomap
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This is implemented by a ConcurrentMap to grant exclusive access to its
+// storage, so that compound operations can be performed atomically. The storage
+// must not be retained after fn returns. writeKeyStorage only guarantees
+// exclusive access to key, which lets sharded implementations lock less.
+type storageAccessor interface {
+	Map
+	readStorage(fn func(storage Map))
+	writeStorage(fn func(storage Map))
+	writeKeyStorage(key interface{}, fn func(storage Map))
+}
+
+// The number of stripes in the lock table used by LockKey. Distinct keys may
+// share a stripe, which only causes unnecessary waiting.
+const keyLockStripes = 64
+
+// This implements the compound ConcurrentMap operations once for every
+// implementation that can provide a storageAccessor.
+type concurrentOps struct {
+	accessor storageAccessor
+	keyLocks [keyLockStripes]sync.Mutex
+
+	// LoadOrStore keeps a promise for each key whose value is being computed, so
+	// that valueFn runs outside the storage's critical section.
+	loadMutex sync.Mutex
+	loads     map[interface{}]*loadCall
+}
+
+func entriesOf(storage Map) []Entry {
+	keys := storage.Keys()
+	entries := make([]Entry, 0, len(keys))
+
+	for _, key := range keys {
+		value, _ := storage.Get(key)
+		entries = append(entries, Entry{Key: key, Value: value})
+	}
+
+	return entries
+}
+
+// Take a consistent snapshot of m if it supports one, or read its entries one
+// by one otherwise.
+func snapshotOf(m Map) []Entry {
+	if snapshotter, ok := m.(interface{ snapshot() []Entry }); ok {
+		return snapshotter.snapshot()
+	}
+
+	return entriesOf(m)
+}
+
+func (ops *concurrentOps) snapshot() []Entry {
+	var entries []Entry
+
+	ops.accessor.readStorage(func(storage Map) {
+		entries = entriesOf(storage)
+	})
+
+	return entries
+}
+
+func (ops *concurrentOps) AppendToSlice(key interface{}, values ...interface{}) int {
+	var length int
+
+	ops.accessor.writeKeyStorage(key, func(storage Map) {
+		var slice []interface{}
+
+		if value, found := storage.Get(key); found {
+			var isSlice bool
+
+			if slice, isSlice = value.([]interface{}); !isSlice {
+				slice = []interface{}{value}
+			}
+		}
+
+		slice = append(slice, values...)
+		storage.Set(key, slice)
+		length = len(slice)
+	})
+
+	return length
+}
+
+func (ops *concurrentOps) ApplyDelta(added, removed map[interface{}]interface{}) int {
+	var length int
+
+	ops.accessor.writeStorage(func(storage Map) {
+		for key := range removed {
+			storage.Delete(key)
+		}
+
+		for key, value := range added {
+			storage.Set(key, value)
+		}
+
+		length = storage.Length()
+	})
+
+	return length
+}
+
+// XOR-ing the entry hashes makes the result independent of iteration order.
+func (ops *concurrentOps) Checksum() uint64 {
+	checksum := uint64(0)
+
+	ops.accessor.readStorage(func(storage Map) {
+		for _, key := range storage.Keys() {
+			value, _ := storage.Get(key)
+			checksum ^= hashEntry(key, value)
+		}
+	})
+
+	return checksum
+}
+
+func (ops *concurrentOps) CompareAndSwapMany(updates []CASUpdate, bestEffort bool) (int, bool) {
+	applied := 0
+
+	ops.accessor.writeStorage(func(storage Map) {
+		previous := make([]interface{}, 0, len(updates))
+
+		for _, update := range updates {
+			if value, found := storage.Get(update.Key); found && reflect.DeepEqual(value, update.Old) {
+				storage.Set(update.Key, update.New)
+				previous = append(previous, value)
+				applied++
+			} else if !bestEffort {
+				// Undo in reverse order, so that repeated keys end up with their
+				// original values.
+				for ix := len(previous) - 1; ix >= 0; ix-- {
+					storage.Set(updates[ix].Key, previous[ix])
+				}
+
+				applied = 0
+				return
+			}
+		}
+	})
+
+	return applied, applied == len(updates)
+}
+
+func (ops *concurrentOps) ContainsAll(keys ...interface{}) bool {
+	containsAll := true
+
+	ops.accessor.readStorage(func(storage Map) {
+		for _, key := range keys {
+			if !storage.Contains(key) {
+				containsAll = false
+				return
+			}
+		}
+	})
+
+	return containsAll
+}
+
+func (ops *concurrentOps) ContainsAny(keys ...interface{}) bool {
+	containsAny := false
+
+	ops.accessor.readStorage(func(storage Map) {
+		for _, key := range keys {
+			if storage.Contains(key) {
+				containsAny = true
+				return
+			}
+		}
+	})
+
+	return containsAny
+}
+
+func (ops *concurrentOps) ConvertTo(kind MapKind) Map {
+	return BuildMap(kind, ops.snapshot())
+}
+
+func (ops *concurrentOps) CopyTo(dst map[interface{}]interface{}) int {
+	copied := 0
+
+	ops.accessor.readStorage(func(storage Map) {
+		for _, key := range storage.Keys() {
+			dst[key], _ = storage.Get(key)
+			copied++
+		}
+	})
+
+	return copied
+}
+
+func (ops *concurrentOps) Diff(other Map) (map[interface{}]interface{}, map[interface{}]interface{}, map[interface{}]interface{}) {
+	added := make(map[interface{}]interface{})
+	removed := make(map[interface{}]interface{})
+	changed := make(map[interface{}]interface{})
+	otherEntries := make(map[interface{}]interface{})
+
+	for _, entry := range snapshotOf(other) {
+		otherEntries[entry.Key] = entry.Value
+	}
+
+	for _, entry := range ops.snapshot() {
+		if otherValue, found := otherEntries[entry.Key]; !found {
+			added[entry.Key] = entry.Value
+		} else if !reflect.DeepEqual(entry.Value, otherValue) {
+			changed[entry.Key] = entry.Value
+		}
+
+		delete(otherEntries, entry.Key)
+	}
+
+	for key, value := range otherEntries {
+		removed[key] = value
+	}
+
+	return added, removed, changed
+}
+
+func (ops *concurrentOps) EntriesChan(bufferSize int) <-chan Entry {
+	entries := ops.snapshot()
+	entryCh := make(chan Entry, bufferSize)
+
+	go func() {
+		defer close(entryCh)
+
+		for _, entry := range entries {
+			entryCh <- entry
+		}
+	}()
+
+	return entryCh
+}
+
+func (ops *concurrentOps) EntrySet() EntrySet {
+	return &entrySet{ops: ops}
+}
+
+func (ops *concurrentOps) ForEachParallel(workers int, fn func(key, value interface{})) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	entryCh := make(chan Entry, workers)
+	waitGroup := sync.WaitGroup{}
+
+	for i := 0; i < workers; i++ {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			for entry := range entryCh {
+				fn(entry.Key, entry.Value)
+			}
+		}()
+	}
+
+	for _, entry := range ops.snapshot() {
+		entryCh <- entry
+	}
+
+	close(entryCh)
+	waitGroup.Wait()
+}
+
+func (ops *concurrentOps) GetDeleteIf(key interface{}, predicate func(value interface{}) bool) (interface{}, bool) {
+	var value interface{}
+	deleted := false
+
+	ops.accessor.writeKeyStorage(key, func(storage Map) {
+		var found bool
+
+		if value, found = storage.Get(key); found && predicate(value) {
+			storage.Delete(key)
+			deleted = true
+		}
+	})
+
+	return value, deleted
+}
+
+func (ops *concurrentOps) GetIfPresent(key interface{}) interface{} {
+	value, _ := ops.accessor.Get(key)
+	return value
+}
+
+func (ops *concurrentOps) GetModifySet(key interface{}, modify func(interface{}, bool) interface{}) (interface{}, interface{}) {
+	var oldValue, newValue interface{}
+
+	ops.accessor.writeKeyStorage(key, func(storage Map) {
+		var found bool
+		oldValue, found = storage.Get(key)
+		newValue = modify(oldValue, found)
+		storage.Set(key, newValue)
+	})
+
+	return oldValue, newValue
+}
+
+// fallbackFn runs outside the storage's critical section, since nothing is
+// stored.
+func (ops *concurrentOps) GetOrElse(key interface{}, fallbackFn func() interface{}) interface{} {
+	if value, found := ops.accessor.Get(key); found {
+		return value
+	}
+
+	return fallbackFn()
+}
+
+func (ops *concurrentOps) IncrementBounded(key interface{}, delta, max int64) (int64, bool) {
+	var newValue int64
+	var capped bool
+
+	ops.accessor.writeKeyStorage(key, func(storage Map) {
+		value, _ := storage.Get(key)
+		current, _ := value.(int64)
+
+		// The sum is checked before adding, so that it cannot wrap around.
+		switch {
+		case current > 0 && delta > math.MaxInt64-current:
+			newValue, capped = max, true
+
+		case current < 0 && delta < math.MinInt64-current:
+			newValue = math.MinInt64
+
+		default:
+			if newValue = current + delta; newValue > max {
+				newValue, capped = max, true
+			}
+		}
+
+		storage.Set(key, newValue)
+	})
+
+	return newValue, capped
+}
+
+// Entries are sorted first, so that the error reported does not depend on map
+// iteration order.
+func (ops *concurrentOps) Invert() (Map, error) {
+	entries := ops.snapshot()
+	sortEntries(entries)
+	inverted := NewDefaultBasicMap()
+
+	for _, entry := range entries {
+		if entry.Value != nil && !reflect.TypeOf(entry.Value).Comparable() {
+			return nil, fmt.Errorf("%w: value %v of %v is not comparable", ErrInvalidKey, entry.Value, entry.Key)
+		}
+
+		if existing, found := inverted.Set(entry.Value, entry.Key); found {
+			return nil, fmt.Errorf("%w: %v and %v both map to %v", ErrDuplicateValue, existing, entry.Key, entry.Value)
+		}
+	}
+
+	return inverted, nil
+}
+
+func (ops *concurrentOps) IsEmpty() bool {
+	isEmpty := true
+
+	ops.accessor.readStorage(func(storage Map) {
+		isEmpty = storage.Length() == 0
+	})
+
+	return isEmpty
+}
+
+func (ops *concurrentOps) KeysWhere(predicate func(key interface{}) bool) []interface{} {
+	keys := make([]interface{}, 0)
+
+	ops.accessor.readStorage(func(storage Map) {
+		for _, key := range storage.Keys() {
+			if predicate(key) {
+				keys = append(keys, key)
+			}
+		}
+	})
+
+	return keys
+}
+
+func (ops *concurrentOps) KeysWithPrefix(prefix string) []interface{} {
+	keys := make([]interface{}, 0)
+
+	ops.accessor.readStorage(func(storage Map) {
+		for _, key := range storage.Keys() {
+			if str, ok := key.(string); ok && strings.HasPrefix(str, prefix) {
+				keys = append(keys, key)
+			}
+		}
+	})
+
+	return keys
+}
+
+func (ops *concurrentOps) LoadOrStore(key interface{}, valueFn func() interface{}) (interface{}, bool) {
+	for {
+		ops.loadMutex.Lock()
+
+		if call, ok := ops.loads[key]; ok {
+			ops.loadMutex.Unlock()
+			call.waitGroup.Wait()
+
+			// Retry if valueFn panicked, since there is no value to share.
+			if call.found {
+				return call.value, true
+			}
+
+			continue
+		}
+
+		if value, found := ops.accessor.Get(key); found {
+			ops.loadMutex.Unlock()
+			return value, true
+		}
+
+		if ops.loads == nil {
+			ops.loads = make(map[interface{}]*loadCall)
+		}
+
+		call := &loadCall{}
+		call.waitGroup.Add(1)
+		ops.loads[key] = call
+		ops.loadMutex.Unlock()
+		return ops.storeLoaded(key, call, valueFn)
+	}
+}
+
+// A plain Set may race with valueFn, in which case the value already stored
+// wins.
+func (ops *concurrentOps) storeLoaded(key interface{}, call *loadCall, valueFn func() interface{}) (interface{}, bool) {
+	defer func() {
+		ops.loadMutex.Lock()
+		delete(ops.loads, key)
+		ops.loadMutex.Unlock()
+		call.waitGroup.Done()
+	}()
+
+	value := valueFn()
+	actual, loaded := ops.SetIfAbsent(key, value)
+
+	if !loaded {
+		actual = value
+	}
+
+	call.value, call.found = actual, true
+	return actual, loaded
+}
+
+func (ops *concurrentOps) LockKey(key interface{}) func() {
+	mutex := &ops.keyLocks[hashKey(key)%keyLockStripes]
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+func (ops *concurrentOps) MergeFrom(other Map, combine func(key, existing, incoming interface{}) interface{}) {
+	// Snapshot other before entering this map, so that merging two maps into
+	// each other concurrently cannot deadlock.
+	incoming := snapshotOf(other)
+
+	ops.accessor.writeStorage(func(storage Map) {
+		for _, entry := range incoming {
+			if existing, found := storage.Get(entry.Key); found {
+				storage.Set(entry.Key, combine(entry.Key, existing, entry.Value))
+			} else {
+				storage.Set(entry.Key, entry.Value)
+			}
+		}
+	})
+}
+
+func (ops *concurrentOps) PopMany(keys []interface{}) map[interface{}]interface{} {
+	popped := make(map[interface{}]interface{})
+
+	ops.accessor.writeStorage(func(storage Map) {
+		for _, key := range keys {
+			if value, found := storage.Delete(key); found {
+				popped[key] = value
+			}
+		}
+	})
+
+	return popped
+}
+
+func (ops *concurrentOps) RotateValue(key interface{}, options []interface{}) interface{} {
+	if len(options) == 0 {
+		return nil
+	}
+
+	next := options[0]
+
+	ops.accessor.writeKeyStorage(key, func(storage Map) {
+		if value, found := storage.Get(key); found {
+			for ix, option := range options {
+				if reflect.DeepEqual(value, option) {
+					next = options[(ix+1)%len(options)]
+					break
+				}
+			}
+		}
+
+		storage.Set(key, next)
+	})
+
+	return next
+}
+
+// This uses reservoir sampling over a sorted snapshot, so that the result only
+// depends on the state of rng and not on map iteration order.
+func (ops *concurrentOps) Sample(k int, rng *rand.Rand) []Entry {
+	entries := ops.snapshot()
+
+	if k >= len(entries) {
+		return entries
+	}
+
+	if k <= 0 {
+		return make([]Entry, 0)
+	}
+
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	sortEntries(entries)
+	sample := make([]Entry, k)
+	copy(sample, entries[:k])
+
+	for ix := k; ix < len(entries); ix++ {
+		if jx := rng.Intn(ix + 1); jx < k {
+			sample[jx] = entries[ix]
+		}
+	}
+
+	return sample
+}
+
+func (ops *concurrentOps) ScopeByPrefix(prefix string) Map {
+	return &scopedMap{ops: ops, prefix: prefix}
+}
+
+func (ops *concurrentOps) SetAndThen(key interface{}, value interface{}, after func(prev interface{}, existed bool)) {
+	prev, existed := ops.accessor.Set(key, value)
+	after(prev, existed)
+}
+
+func (ops *concurrentOps) SetIfAbsent(key interface{}, value interface{}) (interface{}, bool) {
+	var existing interface{}
+	var found bool
+
+	ops.accessor.writeKeyStorage(key, func(storage Map) {
+		if existing, found = storage.Get(key); !found {
+			storage.Set(key, value)
+		}
+	})
+
+	return existing, found
+}
+
+func (ops *concurrentOps) SetIfChanged(key interface{}, value interface{}) bool {
+	changed := false
+
+	ops.accessor.writeKeyStorage(key, func(storage Map) {
+		if existing, found := storage.Get(key); !found || !reflect.DeepEqual(existing, value) {
+			storage.Set(key, value)
+			changed = true
+		}
+	})
+
+	return changed
+}
+
+func (ops *concurrentOps) StorageKind() string {
+	var kind string
+
+	ops.accessor.readStorage(func(storage Map) {
+		kind = storageKindOf(storage)
+	})
+
+	return kind
+}
+
+func (ops *concurrentOps) TakeSlice(key interface{}) ([]interface{}, bool) {
+	var slice []interface{}
+	var taken bool
+
+	ops.accessor.writeKeyStorage(key, func(storage Map) {
+		value, _ := storage.Get(key)
+
+		if slice, taken = value.([]interface{}); taken {
+			storage.Delete(key)
+		}
+	})
+
+	return slice, taken
+}
+
+func (ops *concurrentOps) TopN(n int, less func(a, b interface{}) bool) []Entry {
+	top := &entryHeap{entries: make([]Entry, 0), less: less}
+
+	if n <= 0 {
+		return top.entries
+	}
+
+	ops.accessor.readStorage(func(storage Map) {
+		for _, key := range storage.Keys() {
+			value, _ := storage.Get(key)
+			entry := Entry{Key: key, Value: value}
+
+			if top.Len() < n {
+				heap.Push(top, entry)
+			} else if less(top.entries[0].Value, value) {
+				top.entries[0] = entry
+				heap.Fix(top, 0)
+			}
+		}
+	})
+
+	results := make([]Entry, top.Len())
+
+	for ix := len(results) - 1; ix >= 0; ix-- {
+		results[ix] = heap.Pop(top).(Entry)
+	}
+
+	return results
+}
+
+func (ops *concurrentOps) TryGet(key interface{}) (interface{}, bool) {
+	return ops.accessor.Get(key)
+}
+
+func (ops *concurrentOps) UpdateValuesOfType(t reflect.Type, mutate func(value interface{}) interface{}) int {
+	return ops.UpdateWhere(func(key, value interface{}) bool {
+		return value != nil && reflect.TypeOf(value) == t
+	}, func(key, value interface{}) interface{} {
+		return mutate(value)
+	})
+}
+
+func (ops *concurrentOps) UpdateWhere(predicate func(key, value interface{}) bool, mutate func(key, value interface{}) interface{}) int {
+	updated := 0
+
+	ops.accessor.writeStorage(func(storage Map) {
+		for _, entry := range entriesOf(storage) {
+			if predicate(entry.Key, entry.Value) {
+				storage.Set(entry.Key, mutate(entry.Key, entry.Value))
+				updated++
+			}
+		}
+	})
+
+	return updated
+}
+
+func (ops *concurrentOps) Verify() error {
+	var err error
+
+	ops.accessor.readStorage(func(storage Map) {
+		keys := storage.Keys()
+		seen := make(map[interface{}]bool, len(keys))
+
+		if length := storage.Length(); length != len(keys) {
+			err = fmt.Errorf("%w: length %d but %d keys", ErrInvariantViolated, length, len(keys))
+			return
+		}
+
+		for _, key := range keys {
+			if seen[key] {
+				err = fmt.Errorf("%w: duplicate key %v", ErrInvariantViolated, key)
+				return
+			}
+
+			if !storage.Contains(key) {
+				err = fmt.Errorf("%w: key %v cannot be looked up", ErrInvariantViolated, key)
+				return
+			}
+
+			seen[key] = true
+		}
+	})
+
+	return err
+}
@@ -0,0 +1,1254 @@
+package gomap
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gl "github.com/protoman92/gocontainer/pkg/gocollection"
+)
+
+func testConcurrentMapAppendToSliceTakeSlice(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	goroutineCount := 8
+	appendCount := 200
+	taken := make(chan []interface{}, goroutineCount*appendCount)
+	appendGroup := sync.WaitGroup{}
+	appendGroup.Add(goroutineCount)
+	stopCh := make(chan interface{})
+	takeDone := make(chan interface{})
+
+	/// When
+	for i := 0; i < goroutineCount; i++ {
+		go func(offset int) {
+			defer appendGroup.Done()
+
+			for j := 0; j < appendCount; j++ {
+				cm.AppendToSlice("queue", offset*appendCount+j)
+			}
+		}(i)
+	}
+
+	go func() {
+		defer close(takeDone)
+
+		for {
+			select {
+			case <-stopCh:
+				return
+
+			default:
+				if slice, found := cm.TakeSlice("queue"); found {
+					taken <- slice
+				}
+			}
+		}
+	}()
+
+	appendGroup.Wait()
+	close(stopCh)
+	<-takeDone
+
+	if slice, found := cm.TakeSlice("queue"); found {
+		taken <- slice
+	}
+
+	close(taken)
+
+	/// Then
+	seen := make(map[interface{}]bool)
+
+	for slice := range taken {
+		for _, value := range slice {
+			if seen[value] {
+				t.Errorf("Should take %v only once", value)
+			}
+
+			seen[value] = true
+		}
+	}
+
+	if len(seen) != goroutineCount*appendCount {
+		t.Errorf("Should not lose appended values, but got %d", len(seen))
+	}
+
+	cm.Set("scalar", 1)
+
+	if length := cm.AppendToSlice("scalar", 2); length != 2 {
+		t.Errorf("Should wrap a non-slice value, but got length %d", length)
+	}
+
+	if _, found := cm.TakeSlice("missing"); found {
+		t.Errorf("Should not take a missing key")
+	}
+}
+
+func testConcurrentMapApplyDelta(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("a", 1)
+	cm.Set("b", 1)
+	added := map[interface{}]interface{}{"b": 2, "c": 2}
+	removed := map[interface{}]interface{}{"a": 1}
+	stopCh := make(chan interface{})
+	doneCh := make(chan interface{})
+
+	go func() {
+		defer close(doneCh)
+
+		for {
+			select {
+			case <-stopCh:
+				return
+
+			default:
+				entries := make(map[interface{}]interface{})
+
+				for entry := range cm.EntriesChan(0) {
+					entries[entry.Key] = entry.Value
+				}
+
+				before := len(entries) == 2 && entries["a"] == 1 && entries["b"] == 1
+				after := len(entries) == 2 && entries["b"] == 2 && entries["c"] == 2
+
+				if !before && !after {
+					t.Errorf("Should not observe half-applied delta, but got %v", entries)
+				}
+			}
+		}
+	}()
+
+	/// When
+	length := cm.ApplyDelta(added, removed)
+	time.Sleep(time.Millisecond)
+	close(stopCh)
+	<-doneCh
+
+	/// Then
+	if length != 2 {
+		t.Errorf("Should have 2 elements, but got %d", length)
+	}
+
+	if cm.Contains("a") || cm.GetIfPresent("b") != 2 || cm.GetIfPresent("c") != 2 {
+		t.Errorf("Should have applied delta, but got %v", cm)
+	}
+}
+
+func testConcurrentMapChecksum(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	other := NewLockConcurrentMap(NewDefaultBasicMap())
+
+	for i := 0; i < 100; i++ {
+		cm.Set(i, i*2)
+		other.Set(99-i, (99-i)*2)
+	}
+
+	/// When & Then
+	if cm.Checksum() != other.Checksum() {
+		t.Errorf("Should produce same checksum for identical maps")
+	}
+
+	checksum := cm.Checksum()
+	cm.Set(50, 0)
+
+	if cm.Checksum() == checksum {
+		t.Errorf("Should change checksum when a value changes")
+	}
+
+	cm.Set(50, 100)
+
+	if cm.Checksum() != checksum {
+		t.Errorf("Should restore checksum when value is restored")
+	}
+
+	cm.Set("50", 100)
+
+	if cm.Checksum() == checksum {
+		t.Errorf("Should distinguish keys of different types")
+	}
+}
+
+func testConcurrentMapCompareAndSwapMany(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("a", 1)
+	cm.Set("b", 2)
+	cm.Set("c", 3)
+
+	/// When & Then
+	allMatch := []CASUpdate{{Key: "a", Old: 1, New: 10}, {Key: "b", Old: 2, New: 20}}
+
+	if applied, ok := cm.CompareAndSwapMany(allMatch, false); applied != 2 || !ok {
+		t.Errorf("Should apply all matching updates, but got %d, %t", applied, ok)
+	}
+
+	partialMatch := []CASUpdate{{Key: "a", Old: 10, New: 100}, {Key: "c", Old: 0, New: 30}, {Key: "d", Old: nil, New: 40}}
+
+	if applied, ok := cm.CompareAndSwapMany(partialMatch, false); applied != 0 || ok {
+		t.Errorf("Should apply nothing on partial match, but got %d, %t", applied, ok)
+	}
+
+	if value, _ := cm.Get("a"); value != 10 {
+		t.Errorf("Should roll back applied updates, but got %v", value)
+	}
+
+	if applied, ok := cm.CompareAndSwapMany(partialMatch, true); applied != 1 || ok {
+		t.Errorf("Should apply matching updates in best-effort mode, but got %d, %t", applied, ok)
+	}
+
+	expected := map[interface{}]interface{}{"a": 100, "b": 20, "c": 3}
+
+	if actual := goMapOf(cm); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should leave %v, but got %v", expected, actual)
+	}
+
+	/// Setup
+	goroutineCount := 8
+	incrementCount := 200
+	cm.Set("x", 0)
+	cm.Set("y", 0)
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(goroutineCount)
+
+	/// When
+	for i := 0; i < goroutineCount; i++ {
+		go func() {
+			defer waitGroup.Done()
+
+			for j := 0; j < incrementCount; {
+				x, _ := cm.Get("x")
+				y, _ := cm.Get("y")
+
+				updates := []CASUpdate{
+					{Key: "x", Old: x, New: x.(int) + 1},
+					{Key: "y", Old: y, New: y.(int) - 1},
+				}
+
+				if _, ok := cm.CompareAndSwapMany(updates, false); ok {
+					j++
+				}
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+
+	/// Then
+	x, _ := cm.Get("x")
+	y, _ := cm.Get("y")
+
+	if x != goroutineCount*incrementCount || y != -goroutineCount*incrementCount {
+		t.Errorf("Should apply every transaction atomically, but got %v, %v", x, y)
+	}
+}
+
+func testConcurrentMapContainsAllAny(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set(1, 1)
+	cm.Set(2, 2)
+	cm.Set(3, 3)
+
+	/// When & Then
+	if !cm.ContainsAll(1, 2, 3) || !cm.ContainsAny(1, 2, 3) {
+		t.Errorf("Should contain fully present keys")
+	}
+
+	if cm.ContainsAll(1, 2, 4) || !cm.ContainsAny(1, 2, 4) {
+		t.Errorf("Should partially contain keys")
+	}
+
+	if cm.ContainsAll(4, 5) || cm.ContainsAny(4, 5) {
+		t.Errorf("Should not contain absent keys")
+	}
+
+	if !cm.ContainsAll() || cm.ContainsAny() {
+		t.Errorf("Should handle empty key list")
+	}
+}
+
+func testConcurrentMapConvertTo(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	for i := 0; i < 10; i++ {
+		cm.Set(i, i)
+	}
+
+	expected := goMapOf(cm)
+
+	for _, kind := range []MapKind{BasicMapKind, LockConcurrentMapKind, ChannelConcurrentMapKind, ShardedConcurrentMapKind} {
+		/// When
+		converted := cm.ConvertTo(kind)
+
+		/// Then
+		if actual := goMapOf(converted); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Should preserve contents converting to %v, but got %v", kind, actual)
+		}
+
+		if ccm, ok := converted.(ChannelConcurrentMap); ok {
+			ccm.Close()
+		}
+	}
+}
+
+func testConcurrentMapCopyTo(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("a", 1)
+	cm.Set("b", 2)
+	dst := map[interface{}]interface{}{"b": 0, "other": 3}
+
+	/// When
+	copied := cm.CopyTo(dst)
+
+	/// Then
+	if copied != 2 {
+		t.Errorf("Should have copied 2 entries, but got %d", copied)
+	}
+
+	expected := map[interface{}]interface{}{"a": 1, "b": 2, "other": 3}
+
+	if !reflect.DeepEqual(dst, expected) {
+		t.Errorf("Should have filled dst and preserved other keys, but got %v", dst)
+	}
+}
+
+func testConcurrentMapDiff(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	previous := NewLockConcurrentMap(NewDefaultBasicMap())
+	previous.Set("same", 1)
+	previous.Set("changed", []int{1})
+	previous.Set("removed", 3)
+	cm.Set("same", 1)
+	cm.Set("changed", []int{2})
+	cm.Set("added", 4)
+
+	/// When
+	added, removed, changed := cm.Diff(previous)
+
+	/// Then
+	if len(added) != 1 || added["added"] != 4 {
+		t.Errorf("Should have added entries, but got %v", added)
+	}
+
+	if len(removed) != 1 || removed["removed"] != 3 {
+		t.Errorf("Should have removed entries, but got %v", removed)
+	}
+
+	if len(changed) != 1 || changed["changed"].([]int)[0] != 2 {
+		t.Errorf("Should have changed entries, but got %v", changed)
+	}
+
+	if added, removed, changed := cm.Diff(cm); len(added)+len(removed)+len(changed) != 0 {
+		t.Errorf("Should not differ from itself")
+	}
+}
+
+func testConcurrentMapEntriesChan(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	keyCount := 100
+
+	for ix := 0; ix < keyCount; ix++ {
+		cm.Set(ix, -ix)
+	}
+
+	entryCh := cm.EntriesChan(10)
+
+	/// When
+	entries := make(map[interface{}]interface{})
+
+	for entry := range entryCh {
+		entries[entry.Key] = entry.Value
+	}
+
+	/// Then
+	if length := len(entries); length != keyCount {
+		t.Errorf("Should have %d entries, but got %d", keyCount, length)
+	}
+
+	for ix := 0; ix < keyCount; ix++ {
+		if value := entries[ix]; value != -ix {
+			t.Errorf("Should have entry %d with value %d, but got %v", ix, -ix, value)
+		}
+	}
+
+	if _, ok := <-entryCh; ok {
+		t.Errorf("Should have closed channel")
+	}
+}
+
+func testConcurrentMapEntrySet(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("a", 1)
+	cm.Set("b", []int{2})
+	entries := cm.EntrySet()
+
+	/// When & Then
+	if !entries.Contains(Entry{Key: "b", Value: []int{2}}) || entries.Contains(Entry{Key: "a", Value: 2}) {
+		t.Errorf("Should match entries by key and value")
+	}
+
+	if entries.Remove(Entry{Key: "a", Value: 0}) || !cm.Contains("a") {
+		t.Errorf("Should not remove entry with stale value")
+	}
+
+	if !entries.Remove(Entry{Key: "a", Value: 1}) || cm.Contains("a") {
+		t.Errorf("Should remove entry with exact value")
+	}
+
+	cm.Set("c", 3)
+
+	if entries.Length() != 2 || len(entries.Entries()) != 2 {
+		t.Errorf("Should reflect later changes to the map")
+	}
+}
+
+func testConcurrentMapForEachParallel(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	keyCount := 1000
+	visits := make([]int32, keyCount)
+
+	for ix := 0; ix < keyCount; ix++ {
+		cm.Set(ix, ix)
+	}
+
+	/// When
+	cm.ForEachParallel(8, func(key, value interface{}) {
+		atomic.AddInt32(&visits[key.(int)], 1)
+	})
+
+	/// Then
+	for ix := range visits {
+		if count := atomic.LoadInt32(&visits[ix]); count != 1 {
+			t.Errorf("Should visit %d exactly once, but got %d", ix, count)
+		}
+	}
+}
+
+func testConcurrentMapGetDeleteIf(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("ready", 1)
+	cm.Set("pending", 0)
+	isReady := func(value interface{}) bool { return value.(int) > 0 }
+
+	/// When & Then
+	if value, deleted := cm.GetDeleteIf("ready", isReady); value != 1 || !deleted || cm.Contains("ready") {
+		t.Errorf("Should delete and return value if predicate passes")
+	}
+
+	if value, deleted := cm.GetDeleteIf("pending", isReady); value != 0 || deleted || !cm.Contains("pending") {
+		t.Errorf("Should keep and return value if predicate fails")
+	}
+
+	called := false
+
+	if value, deleted := cm.GetDeleteIf("absent", func(interface{}) bool {
+		called = true
+		return true
+	}); value != nil || deleted || called {
+		t.Errorf("Should not call predicate for absent key")
+	}
+}
+
+func testConcurrentMapSetIfAbsent(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	key := "Key"
+
+	/// When & Then
+	if existing, found := cm.SetIfAbsent(key, 1); found || existing != nil {
+		t.Errorf("Should set absent key")
+	}
+
+	if existing, found := cm.SetIfAbsent(key, 2); !found || existing != 1 {
+		t.Errorf("Should return existing value, but got %v", existing)
+	}
+
+	if value, _ := cm.Get(key); value != 1 {
+		t.Errorf("Should not overwrite existing value, but got %v", value)
+	}
+}
+
+func testConcurrentMapGetIfPresent(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("Nil", nil)
+	cm.Set("Key", "Value")
+
+	/// When & Then
+	if value := cm.GetIfPresent("Key"); value != "Value" {
+		t.Errorf("Should get present value, but got %v", value)
+	}
+
+	if value := cm.GetIfPresent("Nil"); value != nil {
+		t.Errorf("Should get nil value, but got %v", value)
+	}
+
+	if value := cm.GetIfPresent("Missing"); value != nil {
+		t.Errorf("Should get nil for missing key, but got %v", value)
+	}
+
+	if value, found := cm.TryGet("Nil"); !found || value != nil {
+		t.Errorf("Should find key with nil value")
+	}
+
+	if value, found := cm.TryGet("Missing"); found || value != nil {
+		t.Errorf("Should not find missing key")
+	}
+}
+
+func testConcurrentMapGetModifySet(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	key := "Counter"
+	goroutineCount := 100
+	increment := func(old interface{}, found bool) interface{} {
+		if !found {
+			return 1
+		}
+
+		return old.(int) + 1
+	}
+
+	/// When & Then
+	if oldValue, newValue := cm.GetModifySet(key, increment); oldValue != nil || newValue != 1 {
+		t.Errorf("Should start from missing key, but got %v, %v", oldValue, newValue)
+	}
+
+	if oldValue, newValue := cm.GetModifySet(key, increment); oldValue != 1 || newValue != 2 {
+		t.Errorf("Should return old and new values, but got %v, %v", oldValue, newValue)
+	}
+
+	waitGroup := sync.WaitGroup{}
+
+	for i := 0; i < goroutineCount; i++ {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+			cm.GetModifySet(key, increment)
+		}()
+	}
+
+	waitGroup.Wait()
+
+	if value, _ := cm.Get(key); value != goroutineCount+2 {
+		t.Errorf("Concurrent calls should serialize, but got %v", value)
+	}
+}
+
+func testConcurrentMapGetOrElse(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("present", 1)
+	cm.Set("nil", nil)
+	calls := 0
+	fallbackFn := func() interface{} { calls++; return "fallback" }
+
+	/// When
+	present := cm.GetOrElse("present", fallbackFn)
+	nilValue := cm.GetOrElse("nil", fallbackFn)
+	missing := cm.GetOrElse("missing", fallbackFn)
+
+	/// Then
+	if present != 1 || nilValue != nil || missing != "fallback" {
+		t.Errorf("Should return stored values or fallback, but got %v, %v, %v", present, nilValue, missing)
+	}
+
+	if calls != 1 {
+		t.Errorf("Should only call fallbackFn on a miss, but got %d calls", calls)
+	}
+
+	if cm.Contains("missing") || cm.Length() != 2 {
+		t.Errorf("Should not store the fallback value")
+	}
+}
+
+func testConcurrentMapIncrementBounded(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	goroutineCount := 10
+	incrementCount := 20
+	max := int64(50)
+	cappedCount := int32(0)
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(goroutineCount)
+
+	/// When
+	for i := 0; i < goroutineCount; i++ {
+		go func() {
+			defer waitGroup.Done()
+
+			for j := 0; j < incrementCount; j++ {
+				if _, capped := cm.IncrementBounded("tokens", 1, max); capped {
+					atomic.AddInt32(&cappedCount, 1)
+				}
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+
+	/// Then
+	if value, _ := cm.Get("tokens"); value != max {
+		t.Errorf("Should stop at %d, but got %v", max, value)
+	}
+
+	if expected := int32(goroutineCount*incrementCount) - int32(max); cappedCount != expected {
+		t.Errorf("Should report %d capped increments, but got %d", expected, cappedCount)
+	}
+
+	if value, capped := cm.IncrementBounded("missing", 3, 10); value != 3 || capped {
+		t.Errorf("Should treat missing key as 0, but got %d, %t", value, capped)
+	}
+
+	cm.Set("large", int64(math.MaxInt64-1))
+
+	if value, capped := cm.IncrementBounded("large", 2, math.MaxInt64); value != math.MaxInt64 || !capped {
+		t.Errorf("Should cap overflowing sum, but got %d, %t", value, capped)
+	}
+
+	cm.Set("small", int64(math.MinInt64+1))
+
+	if value, capped := cm.IncrementBounded("small", -2, 0); value != math.MinInt64 || capped {
+		t.Errorf("Should not wrap underflowing sum, but got %d, %t", value, capped)
+	}
+}
+
+func testConcurrentMapInvert(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("a", 1)
+	cm.Set("b", 2)
+
+	/// When
+	inverted, err := cm.Invert()
+
+	/// Then
+	if err != nil {
+		t.Fatalf("Should invert a bijective map, but got %v", err)
+	}
+
+	if actual := goMapOf(inverted); !reflect.DeepEqual(actual, map[interface{}]interface{}{1: "a", 2: "b"}) {
+		t.Errorf("Should swap keys and values, but got %v", actual)
+	}
+
+	cm.Set("c", 1)
+
+	if _, err := cm.Invert(); !errors.Is(err, ErrDuplicateValue) {
+		t.Errorf("Should reject duplicate values, but got %v", err)
+	}
+
+	cm.Delete("c")
+	cm.Set("d", []int{1})
+
+	if _, err := cm.Invert(); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Should reject non-comparable values, but got %v", err)
+	}
+}
+
+func testConcurrentMapIsEmpty(t *testing.T, cm ConcurrentMap) {
+	/// Setup & When & Then
+	if !cm.IsEmpty() {
+		t.Errorf("New map should be empty")
+	}
+
+	cm.Set("Key", nil)
+
+	if cm.IsEmpty() {
+		t.Errorf("Should not be empty")
+	}
+
+	cm.Delete("Key")
+
+	if !cm.IsEmpty() {
+		t.Errorf("Should be empty after delete")
+	}
+}
+
+func testConcurrentMapKeysWhere(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("a", 1)
+	cm.Set("abcd", 2)
+	cm.Set("abcdef", 3)
+	cm.Set(12345, 4)
+
+	longerThan3 := func(key interface{}) bool {
+		str, ok := key.(string)
+		return ok && len(str) > 3
+	}
+
+	/// When
+	keys := gl.NewSliceList(cm.KeysWhere(longerThan3)...)
+
+	/// Then
+	if keys.Length() != 2 || !keys.ContainsAll("abcd", "abcdef") {
+		t.Errorf("Should return string keys longer than 3, but got %v", keys)
+	}
+
+	if len(cm.KeysWhere(func(interface{}) bool { return false })) != 0 {
+		t.Errorf("Should return no keys")
+	}
+}
+
+func testConcurrentMapKeysWithPrefix(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("user:1:profile", 1)
+	cm.Set("user:2:profile", 2)
+	cm.Set("group:1", 3)
+	cm.Set(1, 4)
+	cm.Set([2]string{"user:", "3"}, 5)
+
+	/// When
+	keys := cm.KeysWithPrefix("user:")
+
+	/// Then
+	list := gl.NewSliceList(keys...)
+
+	if length := list.Length(); length != 2 {
+		t.Errorf("Should have 2 keys, but got %d", length)
+	}
+
+	if !list.ContainsAll("user:1:profile", "user:2:profile") {
+		t.Errorf("Should contain matching keys, but got %v", keys)
+	}
+
+	if empty := cm.KeysWithPrefix("missing:"); len(empty) != 0 {
+		t.Errorf("Should not have any key, but got %v", empty)
+	}
+}
+
+func testConcurrentMapLoadOrStore(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	keyCount := 10
+	callerCount := 50
+	computeCounts := make([]int32, keyCount)
+	notLoadedCounts := make([]int32, keyCount)
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(keyCount * callerCount)
+	cm.Set("existing", 1)
+
+	/// When
+	for i := 0; i < keyCount*callerCount; i++ {
+		go func(key int) {
+			defer waitGroup.Done()
+
+			actual, loaded := cm.LoadOrStore(key, func() interface{} {
+				atomic.AddInt32(&computeCounts[key], 1)
+
+				// Other operations should proceed while the value is computed.
+				cm.Get("existing")
+				time.Sleep(time.Millisecond)
+				return key * 10
+			})
+
+			if actual != key*10 {
+				t.Errorf("Should share computed value for %d, but got %v", key, actual)
+			}
+
+			if !loaded {
+				atomic.AddInt32(&notLoadedCounts[key], 1)
+			}
+		}(i % keyCount)
+	}
+
+	waitGroup.Wait()
+
+	/// Then
+	for key := 0; key < keyCount; key++ {
+		if computeCounts[key] != 1 || notLoadedCounts[key] != 1 {
+			t.Errorf("Should compute %d exactly once, but got %d", key, computeCounts[key])
+		}
+	}
+
+	if actual, loaded := cm.LoadOrStore("existing", func() interface{} { return 2 }); actual != 1 || !loaded {
+		t.Errorf("Should load existing value")
+	}
+}
+
+func testConcurrentMapLockKey(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("counter", 0)
+	goroutineCount := 2
+	incrementCount := 50
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(goroutineCount)
+
+	/// When
+	for i := 0; i < goroutineCount; i++ {
+		go func() {
+			defer waitGroup.Done()
+
+			for j := 0; j < incrementCount; j++ {
+				unlock := cm.LockKey("counter")
+				value, _ := cm.Get("counter")
+				runtime.Gosched()
+				cm.Set("counter", value.(int)+1)
+				unlock()
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+
+	/// Then
+	if value, _ := cm.Get("counter"); value != goroutineCount*incrementCount {
+		t.Errorf("Should serialize lockers, but got %v", value)
+	}
+
+	unlock := cm.LockKey("counter")
+	defer unlock()
+	cm.Set("counter", 0)
+
+	if value, _ := cm.Get("counter"); value != 0 {
+		t.Errorf("Should not block regular operations while locked")
+	}
+}
+
+func testConcurrentMapMergeFrom(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	other := NewLockConcurrentMap(NewDefaultBasicMap())
+	other.Set("a", 1)
+	other.Set("b", 2)
+	cm.Set("b", 10)
+	cm.Set("c", 20)
+
+	sum := func(key, existing, incoming interface{}) interface{} {
+		return existing.(int) + incoming.(int)
+	}
+
+	/// When
+	cm.MergeFrom(other, sum)
+
+	/// Then
+	expected := map[interface{}]interface{}{"a": 1, "b": 12, "c": 20}
+
+	if cm.Length() != len(expected) {
+		t.Errorf("Should have merged entries, but got %v", cm)
+	}
+
+	for key, value := range expected {
+		if actual, _ := cm.Get(key); actual != value {
+			t.Errorf("Should have %v for %v, but got %v", value, key, actual)
+		}
+	}
+
+	if other.Length() != 2 {
+		t.Errorf("Should not modify other map")
+	}
+}
+
+func testConcurrentMapPopMany(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	itemCount := 500
+	keys := make([]interface{}, itemCount)
+
+	for i := range keys {
+		keys[i] = i
+	}
+
+	writesDone := make(chan interface{})
+	batches := make([]map[interface{}]interface{}, 0)
+
+	/// When
+	go func() {
+		defer close(writesDone)
+
+		for i := 0; i < itemCount; i++ {
+			cm.Set(i, i)
+		}
+	}()
+
+	for done := false; !done; {
+		select {
+		case <-writesDone:
+			done = true
+
+		default:
+		}
+
+		batches = append(batches, cm.PopMany(keys))
+	}
+
+	/// Then
+	seen := make(map[interface{}]bool)
+
+	for _, batch := range batches {
+		for key, value := range batch {
+			if seen[key] || key != value {
+				t.Errorf("Should pop %v once with its value, but got %v", key, value)
+			}
+
+			seen[key] = true
+		}
+	}
+
+	if len(seen) != itemCount || !cm.IsEmpty() {
+		t.Errorf("Should pop every entry exactly once, but got %d", len(seen))
+	}
+}
+
+func testConcurrentMapRotateValue(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	backends := []interface{}{"a", "b", "c"}
+	expected := []interface{}{"a", "b", "c", "a", "b"}
+
+	/// When & Then
+	for ix, value := range expected {
+		if rotated := cm.RotateValue("client", backends); rotated != value {
+			t.Errorf("Should rotate to %v at %d, but got %v", value, ix, rotated)
+		}
+	}
+
+	if stored, _ := cm.Get("client"); stored != "b" {
+		t.Errorf("Should store rotated value, but got %v", stored)
+	}
+
+	cm.Set("unknown", "z")
+
+	if rotated := cm.RotateValue("unknown", backends); rotated != "a" {
+		t.Errorf("Should restart from first option, but got %v", rotated)
+	}
+
+	if rotated := cm.RotateValue("empty", nil); rotated != nil || cm.Contains("empty") {
+		t.Errorf("Should not store anything without options")
+	}
+}
+
+func testConcurrentMapSample(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	keyCount := 100
+	k := 10
+
+	for ix := 0; ix < keyCount; ix++ {
+		cm.Set(ix, ix)
+	}
+
+	/// When
+	sample1 := cm.Sample(k, rand.New(rand.NewSource(42)))
+	sample2 := cm.Sample(k, rand.New(rand.NewSource(42)))
+	all := cm.Sample(keyCount+1, nil)
+
+	/// Then
+	if len(sample1) != k {
+		t.Fatalf("Should sample %d entries, but got %d", k, len(sample1))
+	}
+
+	seen := make(map[interface{}]bool)
+
+	for ix := range sample1 {
+		if sample1[ix] != sample2[ix] {
+			t.Errorf("Seeded samples should match, but got %v and %v", sample1, sample2)
+			break
+		}
+
+		if seen[sample1[ix].Key] {
+			t.Errorf("Should not sample %v twice", sample1[ix].Key)
+		}
+
+		seen[sample1[ix].Key] = true
+	}
+
+	if len(all) != keyCount {
+		t.Errorf("Should return all entries, but got %d", len(all))
+	}
+}
+
+func testConcurrentMapSampleUniformity(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	keyCount := 10
+	rounds := 2000
+	counts := make([]int, keyCount)
+	rng := rand.New(rand.NewSource(1))
+
+	for ix := 0; ix < keyCount; ix++ {
+		cm.Set(ix, ix)
+	}
+
+	/// When
+	for i := 0; i < rounds; i++ {
+		for _, entry := range cm.Sample(1, rng) {
+			counts[entry.Key.(int)]++
+		}
+	}
+
+	/// Then
+	expected := rounds / keyCount
+
+	for key, count := range counts {
+		if count < expected*7/10 || count > expected*13/10 {
+			t.Errorf("Key %d should be sampled about %d times, but got %d", key, expected, count)
+		}
+	}
+}
+
+func testConcurrentMapScopeByPrefix(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	users := cm.ScopeByPrefix("user:")
+	groups := cm.ScopeByPrefix("group:")
+
+	/// When
+	users.Set("1", "Alice")
+	users.Set("2", "Bob")
+	groups.Set("1", "Admins")
+
+	/// Then
+	if value, found := cm.Get("user:1"); !found || value != "Alice" {
+		t.Errorf("Should write prefixed key to parent")
+	}
+
+	if value, found := groups.Get("1"); !found || value != "Admins" {
+		t.Errorf("Should read within scope, but got %v", value)
+	}
+
+	if length := users.Length(); length != 2 {
+		t.Errorf("Should have 2 scoped keys, but got %d", length)
+	}
+
+	if keys := gl.NewSliceList(users.Keys()...); !keys.ContainsAll("1", "2") || keys.Length() != 2 {
+		t.Errorf("Should strip prefix from keys, but got %v", keys)
+	}
+
+	if _, found := users.Delete("1"); !found || !groups.Contains("1") {
+		t.Errorf("Should only delete within scope")
+	}
+
+	users.Clear()
+
+	if users.Length() != 0 || groups.Length() != 1 || cm.Length() != 1 {
+		t.Errorf("Should only clear within scope")
+	}
+}
+
+func testConcurrentMapSetAndThen(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("key", 1)
+	calls := 0
+	var prevs []interface{}
+	var existeds []bool
+	var valuesAfter []interface{}
+
+	after := func(key interface{}) func(interface{}, bool) {
+		return func(prev interface{}, existed bool) {
+			calls++
+			prevs = append(prevs, prev)
+			existeds = append(existeds, existed)
+			value, _ := cm.Get(key)
+			valuesAfter = append(valuesAfter, value)
+		}
+	}
+
+	/// When
+	cm.SetAndThen("key", 2, after("key"))
+	cm.SetAndThen("new", 3, after("new"))
+
+	/// Then
+	if calls != 2 {
+		t.Fatalf("Should call after exactly once per Set, but got %d calls", calls)
+	}
+
+	if prevs[0] != 1 || !existeds[0] || prevs[1] != nil || existeds[1] {
+		t.Errorf("Should receive the previous values, but got %v, %v", prevs, existeds)
+	}
+
+	if valuesAfter[0] != 2 || valuesAfter[1] != 3 {
+		t.Errorf("Should run after the write, but got %v", valuesAfter)
+	}
+}
+
+func testConcurrentMapSetIfChanged(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	key := "Key"
+
+	/// When & Then
+	if !cm.SetIfChanged(key, []int{1}) {
+		t.Errorf("Should write absent key")
+	}
+
+	if cm.SetIfChanged(key, []int{1}) {
+		t.Errorf("Should skip identical value")
+	}
+
+	if !cm.SetIfChanged(key, []int{2}) {
+		t.Errorf("Should write changed value")
+	}
+
+	if value, _ := cm.Get(key); value.([]int)[0] != 2 {
+		t.Errorf("Should have stored changed value, but got %v", value)
+	}
+
+	if cm.Set("Nil", nil); cm.SetIfChanged("Nil", nil) {
+		t.Errorf("Should skip identical nil value")
+	}
+}
+
+func testConcurrentMapTopN(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	scores := map[string]int{"a": 5, "b": 1, "c": 9, "d": 3, "e": 7}
+	less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+
+	for key, score := range scores {
+		cm.Set(key, score)
+	}
+
+	/// When
+	top := cm.TopN(3, less)
+	all := cm.TopN(10, less)
+
+	/// Then
+	expected := []Entry{{Key: "c", Value: 9}, {Key: "e", Value: 7}, {Key: "a", Value: 5}}
+
+	if len(top) != len(expected) {
+		t.Fatalf("Should have %d entries, but got %v", len(expected), top)
+	}
+
+	for ix := range expected {
+		if top[ix] != expected[ix] {
+			t.Errorf("Should have %v at %d, but got %v", expected[ix], ix, top[ix])
+		}
+	}
+
+	if len(all) != len(scores) || all[0].Value != 9 || all[len(all)-1].Value != 1 {
+		t.Errorf("Should return all entries in order, but got %v", all)
+	}
+
+	if none := cm.TopN(0, less); len(none) != 0 {
+		t.Errorf("Should not return any entry, but got %v", none)
+	}
+}
+
+func testConcurrentMapUpdateValuesOfType(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	cm.Set("int", 1)
+	cm.Set("string", "a")
+	cm.Set("other int", 2)
+	cm.Set("nil", nil)
+
+	/// When
+	updated := cm.UpdateValuesOfType(reflect.TypeOf(0), func(value interface{}) interface{} {
+		return value.(int) * 10
+	})
+
+	/// Then
+	if updated != 2 {
+		t.Errorf("Should update 2 entries, but got %d", updated)
+	}
+
+	expected := map[interface{}]interface{}{"int": 10, "string": "a", "other int": 20, "nil": nil}
+
+	if actual := goMapOf(cm); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should only mutate values of the given type, but got %v", actual)
+	}
+}
+
+func testConcurrentMapUpdateWhere(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	for i := 0; i < 10; i++ {
+		cm.Set(i, i)
+	}
+
+	overThreshold := func(key, value interface{}) bool { return value.(int) > 5 }
+	double := func(key, value interface{}) interface{} { return value.(int) * 2 }
+
+	/// When
+	updated := cm.UpdateWhere(overThreshold, double)
+
+	/// Then
+	if updated != 4 {
+		t.Errorf("Should update 4 entries, but got %d", updated)
+	}
+
+	for i := 0; i < 10; i++ {
+		expected := i
+
+		if i > 5 {
+			expected = i * 2
+		}
+
+		if value, _ := cm.Get(i); value != expected {
+			t.Errorf("Should have %d for %d, but got %v", expected, i, value)
+		}
+	}
+}
+
+func testConcurrentMapVerify(t *testing.T, cm ConcurrentMap) {
+	/// Setup
+	for i := 0; i < 100; i++ {
+		cm.Set(i, i)
+	}
+
+	cm.Delete(50)
+
+	/// When
+	err := cm.Verify()
+
+	/// Then
+	if err != nil {
+		t.Errorf("Should find no violation in a consistent map, but got %v", err)
+	}
+}
+
+func testConcurrentMapAllOps(t *testing.T, cmFn func() ConcurrentMap) {
+	testConcurrentMapAppendToSliceTakeSlice(t, cmFn())
+	testConcurrentMapApplyDelta(t, cmFn())
+	testConcurrentMapChecksum(t, cmFn())
+	testConcurrentMapCompareAndSwapMany(t, cmFn())
+	testConcurrentMapContainsAllAny(t, cmFn())
+	testConcurrentMapConvertTo(t, cmFn())
+	testConcurrentMapCopyTo(t, cmFn())
+	testConcurrentMapDiff(t, cmFn())
+	testConcurrentMapEntriesChan(t, cmFn())
+	testConcurrentMapEntrySet(t, cmFn())
+	testConcurrentMapForEachParallel(t, cmFn())
+	testConcurrentMapGetDeleteIf(t, cmFn())
+	testConcurrentMapGetIfPresent(t, cmFn())
+	testConcurrentMapGetModifySet(t, cmFn())
+	testConcurrentMapGetOrElse(t, cmFn())
+	testConcurrentMapIncrementBounded(t, cmFn())
+	testConcurrentMapInvert(t, cmFn())
+	testConcurrentMapIsEmpty(t, cmFn())
+	testConcurrentMapKeysWhere(t, cmFn())
+	testConcurrentMapKeysWithPrefix(t, cmFn())
+	testConcurrentMapLoadOrStore(t, cmFn())
+	testConcurrentMapLockKey(t, cmFn())
+	testConcurrentMapMergeFrom(t, cmFn())
+	testConcurrentMapPopMany(t, cmFn())
+	testConcurrentMapRotateValue(t, cmFn())
+	testConcurrentMapSample(t, cmFn())
+	testConcurrentMapSampleUniformity(t, cmFn())
+	testConcurrentMapScopeByPrefix(t, cmFn())
+	testConcurrentMapSetAndThen(t, cmFn())
+	testConcurrentMapSetIfAbsent(t, cmFn())
+	testConcurrentMapSetIfChanged(t, cmFn())
+	testConcurrentMapTopN(t, cmFn())
+	testConcurrentMapUpdateValuesOfType(t, cmFn())
+	testConcurrentMapUpdateWhere(t, cmFn())
+	testConcurrentMapVerify(t, cmFn())
+}
+
+func TestChannelConcurrentMapConcurrentMapOps(t *testing.T) {
+	t.Parallel()
+
+	testConcurrentMapAllOps(t, func() ConcurrentMap {
+		return NewChannelConcurrentMap(NewDefaultBasicMap())
+	})
+}
+
+func TestLockConcurrentMapConcurrentMapOps(t *testing.T) {
+	t.Parallel()
+
+	testConcurrentMapAllOps(t, func() ConcurrentMap {
+		return NewLockConcurrentMap(NewDefaultBasicMap())
+	})
+}
+
+func TestShardedConcurrentMapConcurrentMapOps(t *testing.T) {
+	t.Parallel()
+
+	testConcurrentMapAllOps(t, func() ConcurrentMap {
+		return NewDefaultShardedConcurrentMap()
+	})
+}
+
+func TestConsistentHashShardedConcurrentMapConcurrentMapOps(t *testing.T) {
+	t.Parallel()
+
+	testConcurrentMapAllOps(t, func() ConcurrentMap {
+		return NewShardedConcurrentMap(ShardedConcurrentMapParams{VirtualNodes: 64})
+	})
+}
@@ -134,6 +134,22 @@ func setupConcurrentMapOps(params *ConcurrentMapOpsParams) {
 		}
 	}()
 
+	go func() {
+		for i := 0; i < len(keys); i++ {
+			accessWaitGroup().Add(1)
+
+			go func() {
+				time.Sleep(params.opSleepDuration())
+
+				if currentKeys := cm.Keys(); params.log {
+					fmt.Printf("Current keys: %v\n", currentKeys)
+				}
+
+				accessWaitGroup().Done()
+			}()
+		}
+	}()
+
 	accessWaitGroup().Wait()
 
 	/// Then
@@ -176,6 +192,12 @@ func BenchmarkLockConcurrentMapConcurrentOps(b *testing.B) {
 	})
 }
 
+func BenchmarkShardedConcurrentMapConcurrentOps(b *testing.B) {
+	benchmarkConcurrentMapConcurrentOps(b, func() Map {
+		return NewDefaultShardedConcurrentMap()
+	})
+}
+
 func TestChannelConcurrentMapConcurrentOps(t *testing.T) {
 	bm := NewDefaultBasicMap()
 	cm := NewChannelConcurrentMap(bm)
@@ -189,3 +211,9 @@ func TestLockConcurrentMapConcurrentOps(t *testing.T) {
 	testConcurrentMapConcurrentOps(t, cm)
 	fmt.Printf("Final map %v\n", cm)
 }
+
+func TestShardedConcurrentMapConcurrentOps(t *testing.T) {
+	cm := NewDefaultShardedConcurrentMap()
+	testConcurrentMapConcurrentOps(t, cm)
+	fmt.Printf("Final map %v\n", cm)
+}
@@ -0,0 +1,145 @@
+package gomap_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/protoman92/gocontainer/pkg/gomap"
+	"github.com/protoman92/gocontainer/pkg/gomap/gomaptest"
+)
+
+func TestBasicMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewDefaultBasicMap()
+	})
+}
+
+func TestChannelConcurrentMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewChannelConcurrentMap(gomap.NewDefaultBasicMap())
+	})
+}
+
+func TestLockConcurrentMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewLockConcurrentMap(gomap.NewDefaultBasicMap())
+	})
+}
+
+func TestShardedConcurrentMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewDefaultShardedConcurrentMap()
+	})
+}
+
+func TestAdaptiveMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewAdaptiveMap(gomap.AdaptiveMapParams{})
+	})
+}
+
+func TestCoalescingMapConformance(t *testing.T) {
+	t.Parallel()
+
+	// The window is long enough that writes stay buffered for the whole test.
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewCoalescingMap(gomap.NewDefaultBasicMap(), time.Hour)
+	})
+}
+
+func TestDefensiveMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewDefensiveMap(gomap.NewDefaultBasicMap(), nil)
+	})
+}
+
+func TestMirrorMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewMirrorMap(gomap.NewDefaultBasicMap(), gomap.NewDefaultBasicMap())
+	})
+}
+
+func TestObservableMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewObservableMap(gomap.NewDefaultBasicMap())
+	})
+}
+
+func TestReadThroughMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewReadThroughMap(gomap.NewDefaultBasicMap(), func(key interface{}) (interface{}, bool, error) {
+			return nil, false, nil
+		})
+	})
+}
+
+func TestReplicatingMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		rm := gomap.NewReplicatingMap(gomap.NewDefaultBasicMap(), nil)
+		rm.ReplicateTo(gomap.NewLockConcurrentMap(gomap.NewDefaultBasicMap()))
+		return rm
+	})
+}
+
+func TestLastAccessMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewLastAccessMap(gomap.NewDefaultBasicMap())
+	})
+}
+
+func TestIdleEvictionMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewIdleEvictionMap(gomap.NewDefaultBasicMap(), time.Hour)
+	})
+}
+
+func TestAccumulatorMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewAccumulatorMap(func() interface{} { return 0 }, func(acc, delta interface{}) interface{} {
+			return acc.(int) + delta.(int)
+		})
+	})
+}
+
+func TestSyncMapAdapterConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewFromSyncMap(&sync.Map{})
+	})
+}
+
+func TestSlowLogMapConformance(t *testing.T) {
+	t.Parallel()
+
+	gomaptest.RunMapConformanceTests(t, func() gomap.Map {
+		return gomap.NewSlowLogMap(gomap.NewDefaultBasicMap(), time.Hour, func(string, interface{}, time.Duration) {})
+	})
+}
@@ -0,0 +1,85 @@
+package gomap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+)
+
+type defensiveMap struct {
+	storage Map
+	copier  func(interface{}) interface{}
+}
+
+func (dm *defensiveMap) String() string {
+	return fmt.Sprint(dm.storage)
+}
+
+func (dm *defensiveMap) Clear() {
+	dm.storage.Clear()
+}
+
+func (dm *defensiveMap) Contains(key interface{}) bool {
+	return dm.storage.Contains(key)
+}
+
+func (dm *defensiveMap) Delete(key interface{}) (interface{}, bool) {
+	return dm.storage.Delete(key)
+}
+
+func (dm *defensiveMap) Get(key interface{}) (interface{}, bool) {
+	value, found := dm.storage.Get(key)
+
+	if found {
+		value = dm.copier(value)
+	}
+
+	return value, found
+}
+
+func (dm *defensiveMap) Length() int {
+	return dm.storage.Length()
+}
+
+func (dm *defensiveMap) Keys() []interface{} {
+	return dm.storage.Keys()
+}
+
+func (dm *defensiveMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	return dm.storage.Set(key, dm.copier(value))
+}
+
+// GobCopy returns a deep copy of value by round-tripping it through gob. Values
+// nested in interface fields must be registered with gob.Register, and this
+// panics if value cannot be encoded.
+func GobCopy(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	buffer := bytes.Buffer{}
+
+	if err := gob.NewEncoder(&buffer).Encode(value); err != nil {
+		panic(fmt.Errorf("gomap: unable to copy %T: %w", value, err))
+	}
+
+	copied := reflect.New(reflect.TypeOf(value))
+
+	if err := gob.NewDecoder(&buffer).Decode(copied.Interface()); err != nil {
+		panic(fmt.Errorf("gomap: unable to copy %T: %w", value, err))
+	}
+
+	return copied.Elem().Interface()
+}
+
+// NewDefensiveMap returns a new Map that runs copier on values passed to Set and
+// returned from Get, so that callers never share mutable values such as slices
+// or maps with storage. copier defaults to GobCopy if nil.
+func NewDefensiveMap(storage Map, copier func(interface{}) interface{}) Map {
+	if copier == nil {
+		copier = GobCopy
+	}
+
+	return &defensiveMap{storage: storage, copier: copier}
+}
@@ -0,0 +1,54 @@
+package gomap
+
+import (
+	"testing"
+)
+
+func TestDefensiveMapCopiesValues(t *testing.T) {
+	/// Setup
+	dm := NewDefensiveMap(NewDefaultBasicMap(), nil)
+	slice := []int{1, 2, 3}
+	nested := map[string][]int{"a": {1}}
+
+	/// When
+	dm.Set("Slice", slice)
+	dm.Set("Nested", nested)
+	slice[0] = 100
+	nested["a"][0] = 100
+
+	got, _ := dm.Get("Slice")
+	got.([]int)[1] = 200
+	gotNested, _ := dm.Get("Nested")
+	gotNested.(map[string][]int)["b"] = []int{2}
+
+	/// Then
+	if stored, _ := dm.Get("Slice"); stored.([]int)[0] != 1 || stored.([]int)[1] != 2 {
+		t.Errorf("Should not share slice with callers, but got %v", stored)
+	}
+
+	if stored, _ := dm.Get("Nested"); stored.(map[string][]int)["a"][0] != 1 || len(stored.(map[string][]int)) != 1 {
+		t.Errorf("Should not share nested map with callers, but got %v", stored)
+	}
+}
+
+func TestDefensiveMapCustomCopier(t *testing.T) {
+	/// Setup
+	copies := 0
+
+	copier := func(value interface{}) interface{} {
+		copies++
+		return value
+	}
+
+	dm := NewDefensiveMap(NewDefaultBasicMap(), copier)
+
+	/// When
+	dm.Set("Key", "Value")
+	dm.Get("Key")
+	dm.Get("Missing")
+
+	/// Then
+	if copies != 2 {
+		t.Errorf("Should copy on Set and on found Get, but copied %d times", copies)
+	}
+}
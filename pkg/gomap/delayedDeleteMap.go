@@ -0,0 +1,144 @@
+package gomap
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DelayedDeleteMap represents a Map that can schedule keys for deletion after a
+// grace period, e.g. to debounce invalidations.
+type DelayedDeleteMap interface {
+	Map
+
+	// DeleteAfter deletes key once delay has elapsed, unless key is Set,
+	// Deleted or scheduled again before then. The last call for a key wins.
+	DeleteAfter(key interface{}, delay time.Duration)
+
+	// Close cancels every pending deletion. The map may still be used
+	// afterwards, but DeleteAfter no longer has any effect.
+	Close()
+}
+
+type scheduledDelete struct {
+	timer timer
+}
+
+// A timer that fires after it has been replaced or cancelled finds that its
+// scheduledDelete is no longer the one in pending, and does nothing.
+type delayedDeleteMap struct {
+	mutex   sync.Mutex
+	storage Map
+	clock   clock
+	pending map[interface{}]*scheduledDelete
+	closed  bool
+}
+
+// The caller must hold the mutex.
+func (ddm *delayedDeleteMap) cancel(key interface{}) {
+	if scheduled, found := ddm.pending[key]; found {
+		scheduled.timer.Stop()
+		delete(ddm.pending, key)
+	}
+}
+
+func (ddm *delayedDeleteMap) String() string {
+	return fmt.Sprint(ddm.storage)
+}
+
+func (ddm *delayedDeleteMap) Clear() {
+	ddm.mutex.Lock()
+	defer ddm.mutex.Unlock()
+
+	for key := range ddm.pending {
+		ddm.cancel(key)
+	}
+
+	ddm.storage.Clear()
+}
+
+func (ddm *delayedDeleteMap) Contains(key interface{}) bool {
+	ddm.mutex.Lock()
+	defer ddm.mutex.Unlock()
+	return ddm.storage.Contains(key)
+}
+
+func (ddm *delayedDeleteMap) Delete(key interface{}) (interface{}, bool) {
+	ddm.mutex.Lock()
+	defer ddm.mutex.Unlock()
+	ddm.cancel(key)
+	return ddm.storage.Delete(key)
+}
+
+func (ddm *delayedDeleteMap) Get(key interface{}) (interface{}, bool) {
+	ddm.mutex.Lock()
+	defer ddm.mutex.Unlock()
+	return ddm.storage.Get(key)
+}
+
+func (ddm *delayedDeleteMap) Length() int {
+	ddm.mutex.Lock()
+	defer ddm.mutex.Unlock()
+	return ddm.storage.Length()
+}
+
+func (ddm *delayedDeleteMap) Keys() []interface{} {
+	ddm.mutex.Lock()
+	defer ddm.mutex.Unlock()
+	return ddm.storage.Keys()
+}
+
+func (ddm *delayedDeleteMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	ddm.mutex.Lock()
+	defer ddm.mutex.Unlock()
+	ddm.cancel(key)
+	return ddm.storage.Set(key, value)
+}
+
+func (ddm *delayedDeleteMap) DeleteAfter(key interface{}, delay time.Duration) {
+	ddm.mutex.Lock()
+	defer ddm.mutex.Unlock()
+
+	if ddm.closed {
+		return
+	}
+
+	ddm.cancel(key)
+	scheduled := &scheduledDelete{}
+	ddm.pending[key] = scheduled
+
+	scheduled.timer = ddm.clock.AfterFunc(delay, func() {
+		ddm.mutex.Lock()
+		defer ddm.mutex.Unlock()
+
+		if ddm.pending[key] == scheduled {
+			delete(ddm.pending, key)
+			ddm.storage.Delete(key)
+		}
+	})
+}
+
+func (ddm *delayedDeleteMap) Close() {
+	ddm.mutex.Lock()
+	defer ddm.mutex.Unlock()
+
+	for key := range ddm.pending {
+		ddm.cancel(key)
+	}
+
+	ddm.closed = true
+}
+
+func newDelayedDeleteMap(storage Map, clock clock) *delayedDeleteMap {
+	return &delayedDeleteMap{
+		storage: storage,
+		clock:   clock,
+		pending: make(map[interface{}]*scheduledDelete),
+	}
+}
+
+// NewDelayedDeleteMap returns a new DelayedDeleteMap that stores its entries in
+// storage. Call Close once done to cancel pending deletions.
+func NewDelayedDeleteMap(storage Map) DelayedDeleteMap {
+	return newDelayedDeleteMap(storage, systemClock{})
+}
@@ -0,0 +1,61 @@
+package gomap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelayedDeleteMapDeletesAfterDelay(t *testing.T) {
+	/// Setup
+	delay := time.Minute
+	fakeClock := newFakeClock()
+	ddm := newDelayedDeleteMap(NewDefaultBasicMap(), fakeClock)
+	defer ddm.Close()
+	ddm.Set("idle", 1)
+	ddm.Set("reset", 2)
+
+	/// When
+	ddm.DeleteAfter("idle", delay)
+	ddm.DeleteAfter("reset", delay)
+	fakeClock.Advance(delay / 2)
+	ddm.Set("reset", 3)
+	fakeClock.Advance(delay)
+
+	/// Then
+	if ddm.Contains("idle") {
+		t.Errorf("Should delete key once the delay has elapsed")
+	}
+
+	if value, _ := ddm.Get("reset"); value != 3 {
+		t.Errorf("Should keep key set again within the grace period, but got %v", value)
+	}
+}
+
+func TestDelayedDeleteMapRescheduleAndClose(t *testing.T) {
+	/// Setup
+	delay := time.Minute
+	fakeClock := newFakeClock()
+	ddm := newDelayedDeleteMap(NewDefaultBasicMap(), fakeClock)
+	ddm.Set("rescheduled", 1)
+	ddm.Set("closed", 2)
+
+	/// When
+	ddm.DeleteAfter("rescheduled", delay)
+	fakeClock.Advance(delay / 2)
+	ddm.DeleteAfter("rescheduled", delay)
+	fakeClock.Advance(delay / 2)
+	survivedFirstDeadline := ddm.Contains("rescheduled")
+	fakeClock.Advance(delay / 2)
+	ddm.DeleteAfter("closed", delay)
+	ddm.Close()
+	fakeClock.Advance(delay)
+
+	/// Then
+	if !survivedFirstDeadline || ddm.Contains("rescheduled") {
+		t.Errorf("Should delete at the deadline of the last call only")
+	}
+
+	if !ddm.Contains("closed") || len(ddm.pending) != 0 {
+		t.Errorf("Should cancel pending deletions on Close")
+	}
+}
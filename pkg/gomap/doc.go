@@ -1,5 +1,7 @@
 // Package gomap provides thread-safe ConcurrentMap implementations, of which
-// there are 2: lock-based and channel-based ConcurrentMap. To construct a
-// ConcurrentMap, we need to supply a non-thread safe Map implementation, such
-// as BasicMap. Note that the lock variant is faster than the channel version.
+// there are 3: lock-based, channel-based and sharded ConcurrentMap. To construct
+// a lock or channel ConcurrentMap, we need to supply a non-thread safe Map
+// implementation, such as BasicMap. Note that the lock variant is faster than
+// the channel version, and NewOptimizedConcurrentMap picks one based on the
+// expected workload.
 package gomap
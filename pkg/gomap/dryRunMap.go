@@ -0,0 +1,40 @@
+package gomap
+
+import (
+	"fmt"
+)
+
+// Writes report what they would have done to storage, without changing it.
+type dryRunMap struct {
+	storage Map
+}
+
+func (drm *dryRunMap) String() string {
+	return fmt.Sprint(drm.storage)
+}
+
+func (drm *dryRunMap) Clear() {}
+
+func (drm *dryRunMap) Contains(key interface{}) bool {
+	return drm.storage.Contains(key)
+}
+
+func (drm *dryRunMap) Delete(key interface{}) (interface{}, bool) {
+	return drm.storage.Get(key)
+}
+
+func (drm *dryRunMap) Get(key interface{}) (interface{}, bool) {
+	return drm.storage.Get(key)
+}
+
+func (drm *dryRunMap) Length() int {
+	return drm.storage.Length()
+}
+
+func (drm *dryRunMap) Keys() []interface{} {
+	return drm.storage.Keys()
+}
+
+func (drm *dryRunMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	return drm.storage.Get(key)
+}
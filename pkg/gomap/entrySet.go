@@ -0,0 +1,52 @@
+package gomap
+
+import (
+	"reflect"
+)
+
+// EntrySet represents a live view of the entries of a ConcurrentMap. Entries
+// match if their keys are equal and their values are deeply equal according to
+// reflect.DeepEqual.
+type EntrySet interface {
+	// Contains reports whether the key of entry maps to the value of entry.
+	Contains(entry Entry) bool
+
+	// Entries returns a snapshot of all entries, for iteration.
+	Entries() []Entry
+
+	Length() int
+
+	// Remove atomically deletes the key of entry only if it still maps to the
+	// value of entry, and reports whether it did.
+	Remove(entry Entry) bool
+}
+
+type entrySet struct {
+	ops *concurrentOps
+}
+
+func (es *entrySet) Contains(entry Entry) bool {
+	value, found := es.ops.accessor.Get(entry.Key)
+	return found && reflect.DeepEqual(value, entry.Value)
+}
+
+func (es *entrySet) Entries() []Entry {
+	return es.ops.snapshot()
+}
+
+func (es *entrySet) Length() int {
+	return es.ops.accessor.Length()
+}
+
+func (es *entrySet) Remove(entry Entry) bool {
+	removed := false
+
+	es.ops.accessor.writeKeyStorage(entry.Key, func(storage Map) {
+		if value, found := storage.Get(entry.Key); found && reflect.DeepEqual(value, entry.Value) {
+			storage.Delete(entry.Key)
+			removed = true
+		}
+	})
+
+	return removed
+}
@@ -0,0 +1,59 @@
+package gomap
+
+import (
+	"errors"
+)
+
+var (
+	// ErrLengthMismatch is returned when parallel slices that should pair up
+	// have different lengths.
+	ErrLengthMismatch = errors.New("gomap: slice lengths differ")
+
+	// ErrLoaderPanicked is returned to callers that share a loader call of a
+	// ReadThroughMap when the loader panics.
+	ErrLoaderPanicked = errors.New("gomap: loader panicked")
+
+	// ErrLoopUnresponsive is returned when the loop goroutine of a channel-based
+	// map fails to respond in time, e.g. because it has crashed.
+	ErrLoopUnresponsive = errors.New("gomap: loop goroutine is unresponsive")
+
+	// ErrMapClosed is returned when an operation is attempted on a map that has
+	// already been closed.
+	ErrMapClosed = errors.New("gomap: map is closed")
+
+	// ErrDuplicateValue is returned when a map cannot be inverted because two
+	// keys share a value.
+	ErrDuplicateValue = errors.New("gomap: duplicate value")
+
+	// ErrInvalidKey is returned when a key cannot be used with a map, e.g.
+	// because it is not comparable.
+	ErrInvalidKey = errors.New("gomap: invalid key")
+
+	// ErrInvariantViolated is returned by Verify when the internal state of a map
+	// is inconsistent.
+	ErrInvariantViolated = errors.New("gomap: invariant violated")
+
+	// ErrKeyExists is returned when a write is rejected because the key is
+	// already present.
+	ErrKeyExists = errors.New("gomap: key already exists")
+
+	// ErrRateLimited is returned when a write is rejected because the map's write
+	// rate limit has been exceeded.
+	ErrRateLimited = errors.New("gomap: write rate limit exceeded")
+
+	// ErrReplicationFailed is passed to the error callback of a ReplicatingMap
+	// when a target panics while applying a replicated operation.
+	ErrReplicationFailed = errors.New("gomap: replication failed")
+
+	// ErrTypeMismatch is returned when a value is rejected because its type does
+	// not match the type that a map expects.
+	ErrTypeMismatch = errors.New("gomap: value type mismatch")
+
+	// ErrUnknownRequest is returned when the loop goroutine of a channel-based
+	// map receives a request type it does not recognize.
+	ErrUnknownRequest = errors.New("gomap: unknown request")
+
+	// ErrUnsupportedMode is returned when a map is asked to switch to a
+	// concurrency mode it cannot provide.
+	ErrUnsupportedMode = errors.New("gomap: unsupported concurrency mode")
+)
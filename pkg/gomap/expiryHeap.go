@@ -0,0 +1,38 @@
+package gomap
+
+import (
+	"time"
+)
+
+// A key along with the access time it was queued with.
+type expiryItem struct {
+	key      interface{}
+	accessed time.Time
+}
+
+// Min-heap of keys ordered by access time, for use with container/heap.
+type expiryHeap struct {
+	items []expiryItem
+}
+
+func (h *expiryHeap) Len() int {
+	return len(h.items)
+}
+
+func (h *expiryHeap) Less(i, j int) bool {
+	return h.items[i].accessed.Before(h.items[j].accessed)
+}
+
+func (h *expiryHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(expiryItem))
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,105 @@
+package gomap
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Flatten returns a single-level BasicMap with the entries of m, where values
+// that are themselves Maps or Go maps are expanded at any depth into keys
+// joined by separator, e.g. "a.b.c". Keys are formatted with fmt.Sprint. Empty
+// nested maps are kept as leaf values, so that they are not lost.
+func Flatten(m Map, separator string) Map {
+	flat := NewDefaultBasicMap()
+	flattenInto(flat, "", entriesOfNested(m), separator)
+	return flat
+}
+
+// Return the entries of value if it is a non-empty Map or Go map.
+func entriesOfNested(value interface{}) []Entry {
+	if m, ok := value.(Map); ok {
+		return snapshotOf(m)
+	}
+
+	if reflected := reflect.ValueOf(value); reflected.Kind() == reflect.Map {
+		entries := make([]Entry, 0, reflected.Len())
+		iter := reflected.MapRange()
+
+		for iter.Next() {
+			entries = append(entries, Entry{Key: iter.Key().Interface(), Value: iter.Value().Interface()})
+		}
+
+		return entries
+	}
+
+	return nil
+}
+
+func flattenInto(flat Map, prefix string, entries []Entry, separator string) {
+	for _, entry := range entries {
+		key := fmt.Sprint(entry.Key)
+
+		if prefix != "" {
+			key = prefix + separator + key
+		}
+
+		if nested := entriesOfNested(entry.Value); len(nested) > 0 {
+			flattenInto(flat, key, nested, separator)
+		} else {
+			flat.Set(key, entry.Value)
+		}
+	}
+}
+
+// Unflatten reverses Flatten, splitting string keys of m on separator into
+// nested BasicMaps. Keys that are not strings are copied as they are. If a key
+// is both a leaf and a prefix of other keys, e.g. "a" and "a.b", the nested map
+// wins and the leaf value is dropped, whatever the order of the entries. Leaf
+// values that are themselves Maps are never modified.
+func Unflatten(m Map, separator string) Map {
+	root := NewDefaultBasicMap()
+
+	// Only the nested maps allocated here may be walked into, since a leaf value
+	// may be a Map owned by the caller.
+	owned := map[Map]bool{root: true}
+
+	isOwned := func(value interface{}) bool {
+		nested, isNested := value.(*basicMap)
+		return isNested && owned[nested]
+	}
+
+	for _, entry := range snapshotOf(m) {
+		key, ok := entry.Key.(string)
+
+		if !ok || separator == "" {
+			root.Set(entry.Key, entry.Value)
+			continue
+		}
+
+		parts := strings.Split(key, separator)
+		node := root
+
+		for _, part := range parts[:len(parts)-1] {
+			child, _ := node.Get(part)
+
+			if !isOwned(child) {
+				child = NewDefaultBasicMap()
+				owned[child.(Map)] = true
+				node.Set(part, child)
+			}
+
+			node = child.(Map)
+		}
+
+		leaf := parts[len(parts)-1]
+
+		if existing, _ := node.Get(leaf); isOwned(existing) {
+			continue
+		}
+
+		node.Set(leaf, entry.Value)
+	}
+
+	return root
+}
@@ -0,0 +1,148 @@
+package gomap
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Convert a flat Map to a Go map, for comparisons.
+func goMapOf(m Map) map[interface{}]interface{} {
+	result := make(map[interface{}]interface{})
+
+	for _, entry := range snapshotOf(m) {
+		result[entry.Key] = entry.Value
+	}
+
+	return result
+}
+
+func TestFlattenTwoLevels(t *testing.T) {
+	/// Setup
+	db := NewDefaultBasicMap()
+	db.Set("host", "localhost")
+	db.Set("port", 5432)
+	config := NewLockConcurrentMap(NewDefaultBasicMap())
+	config.Set("db", db)
+	config.Set("debug", true)
+
+	/// When
+	flat := Flatten(config, ".")
+
+	/// Then
+	expected := map[interface{}]interface{}{"db.host": "localhost", "db.port": 5432, "debug": true}
+
+	if actual := goMapOf(flat); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should flatten nested Map, but got %v", actual)
+	}
+}
+
+func TestFlattenThreeLevels(t *testing.T) {
+	/// Setup
+	inner := NewDefaultBasicMap()
+	inner.Set("c", 1)
+	inner.Set(2, map[string]interface{}{"d": "deep"})
+	outer := NewDefaultBasicMap()
+	outer.Set("a", map[string]interface{}{"b": inner, "empty": map[string]int{}})
+
+	/// When
+	flat := Flatten(outer, "/")
+
+	/// Then
+	expected := map[interface{}]interface{}{
+		"a/b/c":   1,
+		"a/b/2/d": "deep",
+		"a/empty": map[string]int{},
+	}
+
+	if actual := goMapOf(flat); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should flatten nested Maps and Go maps, but got %v", actual)
+	}
+}
+
+// Convert nested Maps to nested Go maps, for comparisons.
+func nestedGoMapOf(m Map) map[interface{}]interface{} {
+	result := goMapOf(m)
+
+	for key, value := range result {
+		if nested, ok := value.(Map); ok {
+			result[key] = nestedGoMapOf(nested)
+		}
+	}
+
+	return result
+}
+
+func TestUnflattenRoundTrip(t *testing.T) {
+	/// Setup
+	inner := NewDefaultBasicMap()
+	inner.Set("c", 1)
+	inner.Set("d", "deep")
+	middle := NewDefaultBasicMap()
+	middle.Set("b", inner)
+	middle.Set("e", 2.5)
+	original := NewDefaultBasicMap()
+	original.Set("a", middle)
+	original.Set("f", false)
+
+	/// When
+	restored := Unflatten(Flatten(original, "."), ".")
+
+	/// Then
+	if actual, expected := nestedGoMapOf(restored), nestedGoMapOf(original); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should restore %v, but got %v", expected, actual)
+	}
+}
+
+func TestUnflattenLeafAndPrefixConflict(t *testing.T) {
+	for _, keys := range [][]string{{"a", "a.b"}, {"a.b", "a"}} {
+		/// Setup
+		flat := NewDefaultBasicMap()
+
+		for ix, key := range keys {
+			flat.Set(key, ix)
+		}
+
+		flat.Set(1, "non-string")
+
+		/// When
+		nested := nestedGoMapOf(Unflatten(flat, "."))
+
+		/// Then
+		if a, ok := nested["a"].(map[interface{}]interface{}); !ok || len(a) != 1 || a["b"] == nil {
+			t.Errorf("Should keep nested map over leaf for %v, but got %v", keys, nested)
+		}
+
+		if nested[1] != "non-string" {
+			t.Errorf("Should keep non-string key at top level")
+		}
+	}
+}
+
+func TestUnflattenLeavesCallerMapsAlone(t *testing.T) {
+	for _, reversed := range []bool{false, true} {
+		/// Setup
+		callerMap := NewDefaultBasicMap()
+		callerMap.Set("c", 3)
+		flat := NewLockConcurrentMap(NewDefaultBasicMap())
+
+		if reversed {
+			flat.Set("a.b", 1)
+			flat.Set("a", callerMap)
+		} else {
+			flat.Set("a", callerMap)
+			flat.Set("a.b", 1)
+		}
+
+		/// When
+		nested := nestedGoMapOf(Unflatten(flat, "."))
+
+		/// Then
+		if actual := goMapOf(callerMap); !reflect.DeepEqual(actual, map[interface{}]interface{}{"c": 3}) {
+			t.Errorf("Should not modify a Map passed as a leaf value, but got %v", actual)
+		}
+
+		if expected := map[interface{}]interface{}{"a": map[interface{}]interface{}{"b": 1}}; !reflect.DeepEqual(nested, expected) {
+			t.Errorf("Should keep nested map over leaf, but got %v", nested)
+		}
+	}
+}
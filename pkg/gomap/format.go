@@ -0,0 +1,52 @@
+package gomap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sort entries by their formatted keys, breaking ties by key type, so that the
+// order does not depend on map iteration.
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		keyI, keyJ := fmt.Sprint(entries[i].Key), fmt.Sprint(entries[j].Key)
+
+		if keyI != keyJ {
+			return keyI < keyJ
+		}
+
+		return fmt.Sprintf("%T", entries[i].Key) < fmt.Sprintf("%T", entries[j].Key)
+	})
+}
+
+// Format entries like fmt formats a Go map, i.e. "map[k1:v1 k2:v2]", with keys
+// sorted by sortEntries.
+func formatEntries(entries []Entry) string {
+	return formatEntriesLimit(entries, 0)
+}
+
+// Format at most limit entries like formatEntries, followed by "... (M more)"
+// if some were left out, e.g. "map[k1:v1 ... (2 more)]". A limit that is not
+// positive formats every entry.
+func formatEntriesLimit(entries []Entry, limit int) string {
+	sortEntries(entries)
+	builder := strings.Builder{}
+	builder.WriteString("map[")
+
+	for ix, entry := range entries {
+		if ix > 0 {
+			builder.WriteString(" ")
+		}
+
+		if limit > 0 && ix == limit {
+			fmt.Fprintf(&builder, "... (%d more)", len(entries)-limit)
+			break
+		}
+
+		fmt.Fprintf(&builder, "%v:%v", entry.Key, entry.Value)
+	}
+
+	builder.WriteString("]")
+	return builder.String()
+}
@@ -0,0 +1,59 @@
+package gomap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMapStringIsSortedAndStable(t *testing.T) {
+	/// Setup
+	expected := "map[1:a 10:c 2:b key:value]"
+
+	maps := []Map{
+		NewDefaultBasicMap(),
+		NewLockConcurrentMap(NewDefaultBasicMap()),
+		NewChannelConcurrentMap(NewDefaultBasicMap()),
+		NewDefaultShardedConcurrentMap(),
+	}
+
+	for _, m := range maps {
+		m.Set("key", "value")
+		m.Set(2, "b")
+		m.Set(10, "c")
+		m.Set(1, "a")
+
+		/// When & Then
+		for i := 0; i < 100; i++ {
+			if str := fmt.Sprint(m); str != expected {
+				t.Fatalf("%T should format as %s, but got %s", m, expected, str)
+			}
+		}
+	}
+}
+
+func TestChannelConcurrentMapStringLimit(t *testing.T) {
+	/// Setup
+	cm := NewChannelConcurrentMap(NewDefaultBasicMap(), WithStringLimit(2))
+	defer cm.Close()
+
+	for i := 1; i <= 5; i++ {
+		cm.Set(i, i*10)
+	}
+
+	/// When & Then
+	if str := fmt.Sprint(cm); str != "map[1:10 2:20 ... (3 more)]" {
+		t.Errorf("Should truncate String output, but got %s", str)
+	}
+
+	if str := cm.FullString(); str != "map[1:10 2:20 3:30 4:40 5:50]" {
+		t.Errorf("Should print every entry in FullString, but got %s", str)
+	}
+
+	cm.Delete(5)
+	cm.Delete(4)
+	cm.Delete(3)
+
+	if str := fmt.Sprint(cm); str != "map[1:10 2:20]" {
+		t.Errorf("Should not truncate at the limit, but got %s", str)
+	}
+}
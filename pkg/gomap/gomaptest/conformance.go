@@ -0,0 +1,233 @@
+// Package gomaptest provides a behavioral test suite for Map implementations,
+// so that implementations outside this repository can be validated against the
+// same contract as the ones in gomap.
+package gomaptest
+
+import (
+	"testing"
+
+	gl "github.com/protoman92/gocontainer/pkg/gocollection"
+	"github.com/protoman92/gocontainer/pkg/gomap"
+)
+
+func testMapBasicOps(t *testing.T, m gomap.Map) {
+	/// Setup
+	key := "Key"
+	value := "Value"
+
+	/// When & Then
+	m.Set(key, value)
+
+	if !m.Contains(key) {
+		t.Errorf("Should contain %v", key)
+	}
+
+	if val, found := m.Get(key); val != value || !found {
+		t.Errorf("Should contain %v with value %v", key, value)
+	}
+
+	if prev, found := m.Delete(key); !found || prev == nil {
+		t.Errorf("Should delete key")
+	}
+
+	if val, found := m.Get(key); found || val != nil {
+		t.Errorf("Should not contain %v", key)
+	}
+
+	if prev, found := m.Set(key, value); found || prev != nil {
+		t.Errorf("Should not have any previous value")
+	}
+
+	if length := m.Length(); length != 1 {
+		t.Errorf("Should have length 1, but got %d", length)
+	}
+
+	m.Clear()
+
+	if length := m.Length(); length > 0 {
+		t.Errorf("Should not contain anything")
+	}
+}
+
+func testMapClear(t *testing.T, m gomap.Map) {
+	/// Setup
+	for i := 0; i < 10; i++ {
+		m.Set(i, i)
+	}
+
+	/// When
+	m.Clear()
+
+	/// Then
+	if length := m.Length(); length != 0 {
+		t.Errorf("Should have length 0, but got %d", length)
+	}
+
+	if keys := m.Keys(); len(keys) != 0 {
+		t.Errorf("Should not have any keys, but got %v", keys)
+	}
+
+	if m.Contains(0) {
+		t.Errorf("Should not contain cleared key")
+	}
+
+	if val, found := m.Get(0); found || val != nil {
+		t.Errorf("Should not find cleared key")
+	}
+
+	if prev, found := m.Set(0, 1); found || prev != nil {
+		t.Errorf("Should not have any previous value after clear")
+	}
+
+	if length := m.Length(); length != 1 {
+		t.Errorf("Should be usable after clear, but got length %d", length)
+	}
+}
+
+func testMapDeleteLength(t *testing.T, m gomap.Map) {
+	/// Setup
+	for i := 0; i < 3; i++ {
+		m.Set(i, i)
+	}
+
+	/// When
+	m.Delete(1)
+
+	/// Then
+	if length := m.Length(); length != 2 {
+		t.Errorf("Should have length 2 after delete, but got %d", length)
+	}
+
+	if keys := gl.NewSliceList(m.Keys()...); keys.Length() != 2 || keys.Contains(1) {
+		t.Errorf("Should not return deleted key, but got %v", keys)
+	}
+
+	if prev, found := m.Delete(1); found || prev != nil {
+		t.Errorf("Should not delete key twice")
+	}
+
+	if length := m.Length(); length != 2 {
+		t.Errorf("Should keep length after deleting again, but got %d", length)
+	}
+}
+
+func testMapDeleteMissing(t *testing.T, m gomap.Map) {
+	/// When & Then
+	if prev, found := m.Delete("Missing"); found || prev != nil {
+		t.Errorf("Should not delete from empty map")
+	}
+
+	m.Set("Key", "Value")
+
+	if prev, found := m.Delete("Missing"); found || prev != nil {
+		t.Errorf("Should not delete missing key")
+	}
+
+	if val, found := m.Get("Key"); !found || val != "Value" || m.Length() != 1 {
+		t.Errorf("Should leave other keys alone")
+	}
+}
+
+func testMapContainsNilValue(t *testing.T, m gomap.Map) {
+	/// Setup
+	key := "Nil"
+
+	/// When & Then
+	m.Set(key, nil)
+
+	if !m.Contains(key) || m.Length() != 1 {
+		t.Errorf("Should contain and count key with nil value")
+	}
+
+	if keys := m.Keys(); len(keys) != 1 || keys[0] != key {
+		t.Errorf("Should return key with nil value, but got %v", keys)
+	}
+
+	m.Set(key, 1)
+	m.Set(key, nil)
+
+	if !m.Contains(key) {
+		t.Errorf("Should still contain key after storing nil again")
+	}
+
+	m.Delete(key)
+
+	if m.Contains(key) || m.Length() != 0 {
+		t.Errorf("Should not contain deleted key with nil value")
+	}
+}
+
+func testMapKeys(t *testing.T, m gomap.Map) {
+	/// Setup
+	keys := []interface{}{1, 2, 3, 4, 5}
+
+	/// When
+	for ix := range keys {
+		key := keys[ix]
+		m.Set(key, key)
+	}
+
+	/// Then
+	mapKeys := m.Keys()
+	bl := gl.NewSliceList(mapKeys...)
+
+	if len(mapKeys) != len(keys) {
+		t.Errorf("Should have %d keys, but got %v", len(keys), mapKeys)
+	}
+
+	for ix := range keys {
+		if contains := bl.Contains(keys[ix]); !contains {
+			t.Errorf("Should contain key")
+		}
+	}
+}
+
+func testMapNilValue(t *testing.T, m gomap.Map) {
+	/// Setup
+	nilKey := "Nil"
+	missingKey := "Missing"
+
+	/// When
+	if prev, found := m.Set(nilKey, nil); found || prev != nil {
+		t.Errorf("Should not have any previous value")
+	}
+
+	/// Then
+	if value, found := m.Get(nilKey); !found || value != nil {
+		t.Errorf("Should find key with nil value")
+	}
+
+	if value, found := m.Get(missingKey); found || value != nil {
+		t.Errorf("Should not find missing key")
+	}
+
+	if !m.Contains(nilKey) || m.Contains(missingKey) {
+		t.Errorf("Should only contain key with nil value")
+	}
+
+	if prev, found := m.Set(nilKey, 1); !found || prev != nil {
+		t.Errorf("Should report previous nil value as found")
+	}
+
+	m.Set(nilKey, nil)
+
+	if prev, found := m.Delete(nilKey); !found || prev != nil {
+		t.Errorf("Should report deleted nil value as found")
+	}
+
+	if _, found := m.Delete(missingKey); found {
+		t.Errorf("Should not delete missing key")
+	}
+}
+
+// RunMapConformanceTests runs the Map contract tests against fresh maps
+// returned by factory, one map per test.
+func RunMapConformanceTests(t *testing.T, factory func() gomap.Map) {
+	t.Run("BasicOps", func(t *testing.T) { testMapBasicOps(t, factory()) })
+	t.Run("Clear", func(t *testing.T) { testMapClear(t, factory()) })
+	t.Run("ContainsNilValue", func(t *testing.T) { testMapContainsNilValue(t, factory()) })
+	t.Run("DeleteLength", func(t *testing.T) { testMapDeleteLength(t, factory()) })
+	t.Run("DeleteMissing", func(t *testing.T) { testMapDeleteMissing(t, factory()) })
+	t.Run("Keys", func(t *testing.T) { testMapKeys(t, factory()) })
+	t.Run("NilValue", func(t *testing.T) { testMapNilValue(t, factory()) })
+}
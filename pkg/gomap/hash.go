@@ -0,0 +1,125 @@
+package gomap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"math"
+	"reflect"
+)
+
+// Hash a key by its kind and value so that keys that are == always produce the
+// same hash. Pointers, channels and the like are hashed by address, and never
+// by what they point to or by their String output, which may change while the
+// key is stored. Common scalar keys skip reflection.
+func hashKey(key interface{}) uint64 {
+	hash := fnv.New64a()
+	writeHashable(hash, key)
+	return hash.Sum64()
+}
+
+// Hash an entry the same way as hashKey, covering both its key and its value.
+func hashEntry(key interface{}, value interface{}) uint64 {
+	hash := fnv.New64a()
+	writeHashable(hash, key)
+	writeHashable(hash, value)
+	return hash.Sum64()
+}
+
+func writeHashable(hash hash.Hash64, value interface{}) {
+	switch value := value.(type) {
+	case string:
+		writeHashWord(hash, reflect.String, uint64(len(value)))
+		io.WriteString(hash, value)
+
+	case int:
+		writeHashWord(hash, reflect.Int, uint64(value))
+
+	case int64:
+		writeHashWord(hash, reflect.Int64, uint64(value))
+
+	case uint64:
+		writeHashWord(hash, reflect.Uint64, value)
+
+	default:
+		writeHashableValue(hash, reflect.ValueOf(value))
+	}
+}
+
+func writeHashWord(hash hash.Hash64, kind reflect.Kind, word uint64) {
+	var buffer [9]byte
+	buffer[0] = byte(kind)
+	binary.LittleEndian.PutUint64(buffer[1:], word)
+	hash.Write(buffer[:])
+}
+
+// Values that cannot be compared, such as slices, can only be values and never
+// keys, and are hashed by their formatted representation.
+func writeHashableValue(hash hash.Hash64, value reflect.Value) {
+	switch kind := value.Kind(); kind {
+	case reflect.Invalid:
+		writeHashWord(hash, kind, 0)
+
+	case reflect.Bool:
+		if value.Bool() {
+			writeHashWord(hash, kind, 1)
+		} else {
+			writeHashWord(hash, kind, 0)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeHashWord(hash, kind, uint64(value.Int()))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeHashWord(hash, kind, value.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		writeHashWord(hash, kind, hashableFloatBits(value.Float()))
+
+	case reflect.Complex64, reflect.Complex128:
+		writeHashWord(hash, kind, hashableFloatBits(real(value.Complex())))
+		writeHashWord(hash, kind, hashableFloatBits(imag(value.Complex())))
+
+	case reflect.String:
+		writeHashWord(hash, kind, uint64(value.Len()))
+		io.WriteString(hash, value.String())
+
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		writeHashWord(hash, kind, uint64(value.Pointer()))
+
+	case reflect.Interface:
+		writeHashableValue(hash, value.Elem())
+
+	case reflect.Array:
+		writeHashWord(hash, kind, uint64(value.Len()))
+
+		for ix := 0; ix < value.Len(); ix++ {
+			writeHashableValue(hash, value.Index(ix))
+		}
+
+	case reflect.Struct:
+		writeHashWord(hash, kind, uint64(value.NumField()))
+
+		for ix := 0; ix < value.NumField(); ix++ {
+			writeHashableValue(hash, value.Field(ix))
+		}
+
+	default:
+		writeHashWord(hash, kind, 0)
+
+		if value.CanInterface() {
+			fmt.Fprintf(hash, "%v", value.Interface())
+		}
+	}
+}
+
+// 0 and -0 are equal, so they must hash the same.
+func hashableFloatBits(float float64) uint64 {
+	if float == 0 {
+		return 0
+	}
+
+	return math.Float64bits(float)
+}
@@ -0,0 +1,66 @@
+package gomap
+
+import (
+	"sort"
+)
+
+// A consistent hash ring that places virtualNodes points per shard, so that
+// adding a shard only takes over the keys that fall just before its points.
+// Points depend only on the shard index, so rings of different sizes agree on
+// every key that the extra shards do not claim.
+type hashRing struct {
+	points []uint64
+	shards []int
+}
+
+func (r *hashRing) Len() int {
+	return len(r.points)
+}
+
+func (r *hashRing) Less(i, j int) bool {
+	return r.points[i] < r.points[j]
+}
+
+func (r *hashRing) Swap(i, j int) {
+	r.points[i], r.points[j] = r.points[j], r.points[i]
+	r.shards[i], r.shards[j] = r.shards[j], r.shards[i]
+}
+
+// FNV hashes of short, similar inputs cluster together, so they are mixed with
+// the splitmix64 finalizer to spread points and keys evenly around the ring.
+func mixHash(hash uint64) uint64 {
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	return hash ^ hash>>31
+}
+
+// Return the index of the shard owning the first point at or after hash.
+func (r *hashRing) shardFor(hash uint64) int {
+	hash = mixHash(hash)
+	ix := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+
+	if ix == len(r.points) {
+		ix = 0
+	}
+
+	return r.shards[ix]
+}
+
+func newHashRing(shardCount int, virtualNodes int) *hashRing {
+	ring := &hashRing{
+		points: make([]uint64, 0, shardCount*virtualNodes),
+		shards: make([]int, 0, shardCount*virtualNodes),
+	}
+
+	for shard := 0; shard < shardCount; shard++ {
+		for node := 0; node < virtualNodes; node++ {
+			ring.points = append(ring.points, mixHash(hashKey([2]int{shard, node})))
+			ring.shards = append(ring.shards, shard)
+		}
+	}
+
+	sort.Sort(ring)
+	return ring
+}
@@ -0,0 +1,242 @@
+package gomap
+
+import (
+	"container/heap"
+	"fmt"
+	"time"
+)
+
+// IdleEvictionMap represents a LastAccessMap that evicts entries which have not
+// been accessed for an idle timeout. Unlike a TTL counted from creation, the
+// timeout restarts on every Get or Set. Idle entries are swept periodically,
+// and are also evicted lazily when they are looked up, so they are never
+// observed once idle.
+type IdleEvictionMap interface {
+	LastAccessMap
+
+	// EvictionEvents returns a channel that receives every entry evicted from
+	// then on, buffered for up to 64 entries. Evictions never wait for the
+	// consumer: events that do not fit in the buffer are dropped. The channel is
+	// closed by Stop.
+	EvictionEvents() <-chan Entry
+
+	// Stop cancels periodic sweeps and closes the eviction channel. Idle entries
+	// are still evicted lazily, but no longer reported.
+	Stop()
+
+	// Verify checks that every key with an access time is stored and queued for
+	// sweeping exactly once, and returns an error wrapping ErrInvariantViolated
+	// for the first violation found. This is meant for tests and debugging.
+	Verify() error
+}
+
+// The buffer size of the channel returned by EvictionEvents.
+const evictionEventBuffer = 64
+
+// Each tracked key is queued at most once in expiries, with the access time it
+// had when queued. Accesses do not touch the heap: when a queued time expires,
+// the key is evicted if it has not been accessed since, or queued again with
+// its latest access time otherwise. This keeps sweeps proportional to the
+// number of expired entries rather than the size of the map.
+type idleEvictionMap struct {
+	*lastAccessMap
+	idleTimeout time.Duration
+	sweepTimer  timer
+	stopped     bool
+	evictionCh  chan Entry
+	expiries    expiryHeap
+	queued      map[interface{}]bool
+}
+
+// Record an access to key. The caller must hold the mutex.
+func (iem *idleEvictionMap) touch(key interface{}, now time.Time) {
+	iem.accessed[key] = now
+
+	if !iem.queued[key] {
+		heap.Push(&iem.expiries, expiryItem{key: key, accessed: now})
+		iem.queued[key] = true
+	}
+}
+
+// Evict key if it is idle, and report whether it was. The caller must hold the
+// mutex.
+func (iem *idleEvictionMap) evictIfIdle(key interface{}, now time.Time) bool {
+	accessed, found := iem.accessed[key]
+
+	if !found || now.Sub(accessed) < iem.idleTimeout {
+		return false
+	}
+
+	delete(iem.accessed, key)
+	value, _ := iem.storage.Delete(key)
+
+	if iem.evictionCh != nil && !iem.stopped {
+		select {
+		case iem.evictionCh <- Entry{Key: key, Value: value}:
+		default:
+		}
+	}
+
+	return true
+}
+
+// The caller must hold the mutex.
+func (iem *idleEvictionMap) sweep() {
+	now := iem.clock.Now()
+
+	for iem.expiries.Len() > 0 && now.Sub(iem.expiries.items[0].accessed) >= iem.idleTimeout {
+		item := heap.Pop(&iem.expiries).(expiryItem)
+
+		if accessed, found := iem.accessed[item.key]; found && !iem.evictIfIdle(item.key, now) {
+			heap.Push(&iem.expiries, expiryItem{key: item.key, accessed: accessed})
+		} else {
+			delete(iem.queued, item.key)
+		}
+	}
+}
+
+func (iem *idleEvictionMap) String() string {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+	iem.sweep()
+	return formatEntries(entriesOf(iem.storage))
+}
+
+func (iem *idleEvictionMap) Contains(key interface{}) bool {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+	iem.evictIfIdle(key, iem.clock.Now())
+	return iem.storage.Contains(key)
+}
+
+func (iem *idleEvictionMap) Get(key interface{}) (interface{}, bool) {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+	now := iem.clock.Now()
+	iem.evictIfIdle(key, now)
+	value, found := iem.storage.Get(key)
+
+	if found {
+		iem.touch(key, now)
+	}
+
+	return value, found
+}
+
+func (iem *idleEvictionMap) Length() int {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+	iem.sweep()
+	return iem.storage.Length()
+}
+
+func (iem *idleEvictionMap) Keys() []interface{} {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+	iem.sweep()
+	return iem.storage.Keys()
+}
+
+// An idle previous value is evicted first, so that it is not reported.
+func (iem *idleEvictionMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+	now := iem.clock.Now()
+	iem.evictIfIdle(key, now)
+	iem.touch(key, now)
+	return iem.storage.Set(key, value)
+}
+
+func (iem *idleEvictionMap) LastAccess(key interface{}) (time.Time, bool) {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+	iem.evictIfIdle(key, iem.clock.Now())
+	accessed, found := iem.accessed[key]
+	return accessed, found
+}
+
+func (iem *idleEvictionMap) tick() {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+	iem.sweep()
+
+	if !iem.stopped {
+		iem.sweepTimer = iem.clock.AfterFunc(iem.idleTimeout, iem.tick)
+	}
+}
+
+func (iem *idleEvictionMap) EvictionEvents() <-chan Entry {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+
+	if iem.evictionCh == nil {
+		iem.evictionCh = make(chan Entry, evictionEventBuffer)
+
+		if iem.stopped {
+			close(iem.evictionCh)
+		}
+	}
+
+	return iem.evictionCh
+}
+
+func (iem *idleEvictionMap) Stop() {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+
+	if iem.stopped {
+		return
+	}
+
+	iem.stopped = true
+	iem.sweepTimer.Stop()
+
+	if iem.evictionCh != nil {
+		close(iem.evictionCh)
+	}
+}
+
+func (iem *idleEvictionMap) Verify() error {
+	iem.mutex.Lock()
+	defer iem.mutex.Unlock()
+
+	if queued, items := len(iem.queued), iem.expiries.Len(); queued != items {
+		return fmt.Errorf("%w: %d queued keys but %d expiry items", ErrInvariantViolated, queued, items)
+	}
+
+	for key := range iem.accessed {
+		if !iem.storage.Contains(key) {
+			return fmt.Errorf("%w: access time for absent key %v", ErrInvariantViolated, key)
+		}
+
+		if !iem.queued[key] {
+			return fmt.Errorf("%w: key %v is not queued for sweeping", ErrInvariantViolated, key)
+		}
+	}
+
+	return nil
+}
+
+func newIdleEvictionMap(storage Map, idleTimeout time.Duration, clock clock) *idleEvictionMap {
+	iem := &idleEvictionMap{
+		lastAccessMap: newLastAccessMap(storage, clock),
+		idleTimeout:   idleTimeout,
+		queued:        make(map[interface{}]bool),
+	}
+
+	now := clock.Now()
+
+	for _, key := range storage.Keys() {
+		iem.touch(key, now)
+	}
+
+	iem.sweepTimer = clock.AfterFunc(idleTimeout, iem.tick)
+	return iem
+}
+
+// NewIdleEvictionMap returns a new IdleEvictionMap that stores its entries in
+// storage, sweeping idle entries every idleTimeout. Entries already in storage
+// count as accessed on construction. Call Stop once done to cancel sweeps.
+func NewIdleEvictionMap(storage Map, idleTimeout time.Duration) IdleEvictionMap {
+	return newIdleEvictionMap(storage, idleTimeout, systemClock{})
+}
@@ -0,0 +1,197 @@
+package gomap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdleEvictionMapEvictsIdleEntries(t *testing.T) {
+	/// Setup
+	idleTimeout := time.Minute
+	fakeClock := newFakeClock()
+	storage := NewDefaultBasicMap()
+	iem := newIdleEvictionMap(storage, idleTimeout, fakeClock)
+	defer iem.Stop()
+	iem.Set("active", 1)
+	iem.Set("idle", 2)
+
+	/// When
+	for i := 0; i < 4; i++ {
+		fakeClock.Advance(idleTimeout / 2)
+		iem.Get("active")
+	}
+
+	/// Then
+	if !iem.Contains("active") {
+		t.Errorf("Should keep continuously accessed key")
+	}
+
+	if storage.Contains("idle") {
+		t.Errorf("Should have swept idle key from storage")
+	}
+
+	if _, found := iem.LastAccess("idle"); found {
+		t.Errorf("Should forget access time of evicted key")
+	}
+}
+
+func TestIdleEvictionMapEvictsLazily(t *testing.T) {
+	/// Setup
+	idleTimeout := time.Minute
+	fakeClock := newFakeClock()
+	storage := NewDefaultBasicMap()
+	storage.Set("existing", 0)
+	iem := newIdleEvictionMap(storage, idleTimeout, fakeClock)
+
+	/// When
+	iem.Stop()
+	iem.Set("Key", 1)
+	fakeClock.Advance(idleTimeout)
+
+	/// Then
+	if !storage.Contains("Key") {
+		t.Errorf("Should not sweep after Stop")
+	}
+
+	if _, found := iem.Get("Key"); found {
+		t.Errorf("Should evict idle key on access")
+	}
+
+	if prev, found := iem.Set("existing", 1); found || prev != nil {
+		t.Errorf("Should not report idle previous value")
+	}
+
+	if iem.Length() != 1 || len(iem.Keys()) != 1 {
+		t.Errorf("Should only count entries that are not idle")
+	}
+}
+
+func TestIdleEvictionMapEvictionEvents(t *testing.T) {
+	/// Setup
+	idleTimeout := time.Minute
+	fakeClock := newFakeClock()
+	iem := newIdleEvictionMap(NewDefaultBasicMap(), idleTimeout, fakeClock)
+	evictionCh := iem.EvictionEvents()
+
+	for i := 0; i < evictionEventBuffer+10; i++ {
+		iem.Set(i, i*10)
+	}
+
+	/// When
+	fakeClock.Advance(idleTimeout)
+	iem.Stop()
+
+	/// Then
+	evicted := make(map[interface{}]interface{})
+
+	for entry := range evictionCh {
+		evicted[entry.Key] = entry.Value
+	}
+
+	if len(evicted) != evictionEventBuffer {
+		t.Errorf("Should drop events beyond the buffer, but got %d", len(evicted))
+	}
+
+	for key, value := range evicted {
+		if value != key.(int)*10 {
+			t.Errorf("Should report evicted value for %v, but got %v", key, value)
+		}
+	}
+
+	if iem.EvictionEvents() != evictionCh {
+		t.Errorf("Should return the same channel")
+	}
+
+	iem.Stop()
+}
+
+func TestIdleEvictionMapSweepsReaccessedKeys(t *testing.T) {
+	/// Setup
+	idleTimeout := time.Minute
+	fakeClock := newFakeClock()
+	iem := newIdleEvictionMap(NewDefaultBasicMap(), idleTimeout, fakeClock)
+	defer iem.Stop()
+	iem.Set("deleted", 1)
+	iem.Set("cleared", 2)
+	iem.Delete("deleted")
+	iem.Clear()
+
+	/// When
+	fakeClock.Advance(idleTimeout / 2)
+	iem.Set("deleted", 3)
+	iem.Set("cleared", 4)
+	fakeClock.Advance(idleTimeout / 2)
+	beforeExpiry := iem.Length()
+	fakeClock.Advance(idleTimeout / 2)
+
+	/// Then
+	if beforeExpiry != 2 {
+		t.Errorf("Should keep keys set again after Delete or Clear, but got %d", beforeExpiry)
+	}
+
+	if length := iem.Length(); length != 0 {
+		t.Errorf("Should sweep keys once idle again, but got %d", length)
+	}
+}
+
+// The scan-based sweep that the heap replaced, kept for comparison.
+func scanSweep(iem *idleEvictionMap) {
+	now := iem.clock.Now()
+
+	for key := range iem.accessed {
+		iem.evictIfIdle(key, now)
+	}
+}
+
+// This sweeps a large map in which no entries have expired, which is the common
+// case when expirations are sparse.
+func benchmarkIdleEvictionMapSweep(b *testing.B, sweep func(iem *idleEvictionMap)) {
+	idleTimeout := time.Minute
+	fakeClock := newFakeClock()
+	iem := newIdleEvictionMap(NewDefaultBasicMap(), idleTimeout, fakeClock)
+	defer iem.Stop()
+
+	for i := 0; i < 100000; i++ {
+		iem.Set(i, i)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		iem.mutex.Lock()
+		sweep(iem)
+		iem.mutex.Unlock()
+	}
+}
+
+func BenchmarkIdleEvictionMapHeapSweep(b *testing.B) {
+	benchmarkIdleEvictionMapSweep(b, (*idleEvictionMap).sweep)
+}
+
+func BenchmarkIdleEvictionMapScanSweep(b *testing.B) {
+	benchmarkIdleEvictionMapSweep(b, scanSweep)
+}
+
+func TestIdleEvictionMapVerify(t *testing.T) {
+	/// Setup
+	iem := newIdleEvictionMap(NewDefaultBasicMap(), time.Minute, newFakeClock())
+	defer iem.Stop()
+	iem.Set("a", 1)
+	iem.Set("b", 2)
+	iem.Delete("b")
+
+	if err := iem.Verify(); err != nil {
+		t.Fatalf("Should find no violation in a consistent map, but got %v", err)
+	}
+
+	/// When
+	iem.mutex.Lock()
+	delete(iem.queued, "a")
+	iem.mutex.Unlock()
+
+	/// Then
+	if err := iem.Verify(); !errors.Is(err, ErrInvariantViolated) {
+		t.Errorf("Should detect an unqueued key, but got %v", err)
+	}
+}
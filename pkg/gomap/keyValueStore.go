@@ -0,0 +1,31 @@
+package gomap
+
+// KeyValueStore represents the minimal key-value interface expected by code
+// that only needs to read, write and remove values, so that it does not depend
+// on the full Map surface.
+type KeyValueStore interface {
+	Delete(key interface{})
+	Get(key interface{}) (interface{}, bool)
+	Set(key interface{}, value interface{})
+}
+
+type keyValueStore struct {
+	storage Map
+}
+
+func (kvs *keyValueStore) Delete(key interface{}) {
+	kvs.storage.Delete(key)
+}
+
+func (kvs *keyValueStore) Get(key interface{}) (interface{}, bool) {
+	return kvs.storage.Get(key)
+}
+
+func (kvs *keyValueStore) Set(key interface{}, value interface{}) {
+	kvs.storage.Set(key, value)
+}
+
+// AsKeyValueStore returns a KeyValueStore that forwards every operation to m.
+func AsKeyValueStore(m Map) KeyValueStore {
+	return &keyValueStore{storage: m}
+}
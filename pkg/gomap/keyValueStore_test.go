@@ -0,0 +1,31 @@
+package gomap
+
+import (
+	"testing"
+)
+
+func TestKeyValueStoreForwardsOperations(t *testing.T) {
+	/// Setup
+	storage := NewDefaultBasicMap()
+	storage.Set("existing", 0)
+	kvs := AsKeyValueStore(storage)
+
+	/// When
+	kvs.Set("new", 1)
+	existing, existingFound := kvs.Get("existing")
+	kvs.Delete("existing")
+	_, deletedFound := kvs.Get("existing")
+
+	/// Then
+	if value, _ := storage.Get("new"); value != 1 {
+		t.Errorf("Should forward Set, but got %v", value)
+	}
+
+	if existing != 0 || !existingFound {
+		t.Errorf("Should forward Get, but got %v, %t", existing, existingFound)
+	}
+
+	if deletedFound || storage.Contains("existing") {
+		t.Errorf("Should forward Delete")
+	}
+}
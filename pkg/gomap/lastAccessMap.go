@@ -0,0 +1,104 @@
+package gomap
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LastAccessMap represents a Map that records when each key was last accessed.
+type LastAccessMap interface {
+	Map
+
+	// LastAccess returns the time of the most recent Get or Set of key, and
+	// false if key is absent.
+	LastAccess(key interface{}) (time.Time, bool)
+}
+
+// The mutex covers both the storage and the timestamps, so that LastAccess is
+// always consistent with the contents of the storage.
+type lastAccessMap struct {
+	mutex    sync.Mutex
+	storage  Map
+	clock    clock
+	accessed map[interface{}]time.Time
+}
+
+func (lam *lastAccessMap) String() string {
+	lam.mutex.Lock()
+	defer lam.mutex.Unlock()
+	return fmt.Sprint(lam.storage)
+}
+
+func (lam *lastAccessMap) Clear() {
+	lam.mutex.Lock()
+	defer lam.mutex.Unlock()
+	lam.storage.Clear()
+	lam.accessed = make(map[interface{}]time.Time)
+}
+
+func (lam *lastAccessMap) Contains(key interface{}) bool {
+	lam.mutex.Lock()
+	defer lam.mutex.Unlock()
+	return lam.storage.Contains(key)
+}
+
+func (lam *lastAccessMap) Delete(key interface{}) (interface{}, bool) {
+	lam.mutex.Lock()
+	defer lam.mutex.Unlock()
+	delete(lam.accessed, key)
+	return lam.storage.Delete(key)
+}
+
+func (lam *lastAccessMap) Get(key interface{}) (interface{}, bool) {
+	lam.mutex.Lock()
+	defer lam.mutex.Unlock()
+	value, found := lam.storage.Get(key)
+
+	if found {
+		lam.accessed[key] = lam.clock.Now()
+	}
+
+	return value, found
+}
+
+func (lam *lastAccessMap) Length() int {
+	lam.mutex.Lock()
+	defer lam.mutex.Unlock()
+	return lam.storage.Length()
+}
+
+func (lam *lastAccessMap) Keys() []interface{} {
+	lam.mutex.Lock()
+	defer lam.mutex.Unlock()
+	return lam.storage.Keys()
+}
+
+func (lam *lastAccessMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	lam.mutex.Lock()
+	defer lam.mutex.Unlock()
+	lam.accessed[key] = lam.clock.Now()
+	return lam.storage.Set(key, value)
+}
+
+func (lam *lastAccessMap) LastAccess(key interface{}) (time.Time, bool) {
+	lam.mutex.Lock()
+	defer lam.mutex.Unlock()
+	accessed, found := lam.accessed[key]
+	return accessed, found
+}
+
+func newLastAccessMap(storage Map, clock clock) *lastAccessMap {
+	return &lastAccessMap{
+		storage:  storage,
+		clock:    clock,
+		accessed: make(map[interface{}]time.Time),
+	}
+}
+
+// NewLastAccessMap returns a new LastAccessMap that stores its entries in
+// storage. Entries already in storage have no access time until they are
+// accessed through the LastAccessMap.
+func NewLastAccessMap(storage Map) LastAccessMap {
+	return newLastAccessMap(storage, systemClock{})
+}
@@ -0,0 +1,75 @@
+package gomap
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLastAccessMapLastAccess(t *testing.T) {
+	/// Setup
+	fakeClock := newFakeClock()
+	lam := newLastAccessMap(NewDefaultBasicMap(), fakeClock)
+	start := fakeClock.Now()
+
+	/// When & Then
+	lam.Set("Key", 1)
+
+	if accessed, found := lam.LastAccess("Key"); !found || !accessed.Equal(start) {
+		t.Errorf("Should record Set time, but got %v", accessed)
+	}
+
+	fakeClock.Advance(time.Minute)
+	lam.Get("Key")
+
+	if accessed, _ := lam.LastAccess("Key"); !accessed.Equal(start.Add(time.Minute)) {
+		t.Errorf("Should advance on Get, but got %v", accessed)
+	}
+
+	fakeClock.Advance(time.Minute)
+	lam.Contains("Key")
+	lam.Get("Missing")
+
+	if accessed, _ := lam.LastAccess("Key"); !accessed.Equal(start.Add(time.Minute)) {
+		t.Errorf("Should only count Get and Set as accesses")
+	}
+
+	if _, found := lam.LastAccess("Missing"); found {
+		t.Errorf("Should not record access time for absent key")
+	}
+
+	lam.Delete("Key")
+
+	if _, found := lam.LastAccess("Key"); found {
+		t.Errorf("Should forget access time of deleted key")
+	}
+}
+
+func TestLastAccessMapConcurrentString(t *testing.T) {
+	/// Setup
+	lam := newLastAccessMap(NewDefaultBasicMap(), newFakeClock())
+	setCount := 100
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(1)
+
+	/// When
+	go func() {
+		defer waitGroup.Done()
+
+		for i := 0; i < setCount; i++ {
+			lam.Set(i, i)
+		}
+	}()
+
+	for i := 0; i < setCount; i++ {
+		_ = lam.String()
+	}
+
+	waitGroup.Wait()
+
+	/// Then
+	if str := lam.String(); !strings.Contains(str, "99") {
+		t.Errorf("Should describe every entry, but got %s", str)
+	}
+}
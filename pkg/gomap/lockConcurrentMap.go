@@ -1,25 +1,113 @@
 package gomap
 
 import (
-	"fmt"
 	"sync"
 )
 
+// LockConcurrentMap represents a lock-based ConcurrentMap.
+type LockConcurrentMap interface {
+	ConcurrentMap
+
+	// LiveKeys returns an iterator over the keys of the map that holds the read
+	// lock only briefly per call to Next, and re-validates each key against the
+	// live map, so keys deleted before they are reached are skipped, while keys
+	// set during the iteration may or may not be visited. If the storage is a
+	// ShardedConcurrentMap, the iterator copies the keys of one shard at a time,
+	// so its memory is bounded by the largest shard rather than the whole map.
+	// Other storages cannot resume an iteration, so their keys are copied up
+	// front, as by Keys.
+	LiveKeys() KeyIterator
+
+	// SwapStorage atomically replaces the backing Map with newStorage and returns
+	// the previous one. Existing entries of newStorage become visible at once.
+	SwapStorage(newStorage Map) Map
+}
+
+// KeyIterator visits keys one by one. Next returns false once every key has been
+// visited.
+type KeyIterator interface {
+	Next() (interface{}, bool)
+}
+
+// This is implemented by storages whose keys can be listed one shard at a time,
+// so that an iteration can resume between shards.
+type shardedKeySource interface {
+	shardCount() int
+	shardKeys(index int) []interface{}
+}
+
+// If source is nil, keys holds every key of the storage from the start.
+// Otherwise keys holds the keys of the shard before nextShard, and the next
+// shard is listed once they run out.
+type liveKeyIterator struct {
+	lcm       *lockConcurrentMap
+	source    shardedKeySource
+	nextShard int
+	keys      []interface{}
+}
+
+func (it *liveKeyIterator) Next() (interface{}, bool) {
+	for {
+		for len(it.keys) > 0 {
+			key := it.keys[0]
+			it.keys = it.keys[1:]
+
+			if it.lcm.Contains(key) {
+				return key, true
+			}
+		}
+
+		if it.source == nil || it.nextShard >= it.source.shardCount() {
+			return nil, false
+		}
+
+		it.lcm.mutex.RLock()
+		it.keys = it.source.shardKeys(it.nextShard)
+		it.lcm.mutex.RUnlock()
+		it.nextShard++
+	}
+}
+
 type lockConcurrentMap struct {
+	*concurrentOps
 	mutex   *sync.RWMutex
 	storage Map
+	factory func() Map
+}
+
+func (lcm *lockConcurrentMap) readStorage(fn func(storage Map)) {
+	lcm.mutex.RLock()
+	defer lcm.mutex.RUnlock()
+	fn(lcm.storage)
+}
+
+func (lcm *lockConcurrentMap) writeStorage(fn func(storage Map)) {
+	lcm.mutex.Lock()
+	defer lcm.mutex.Unlock()
+	fn(lcm.storage)
+}
+
+func (lcm *lockConcurrentMap) writeKeyStorage(key interface{}, fn func(storage Map)) {
+	lcm.writeStorage(fn)
 }
 
 func (lcm *lockConcurrentMap) String() string {
 	lcm.mutex.RLock()
 	defer lcm.mutex.RUnlock()
-	return fmt.Sprint(lcm.storage)
+	return formatEntries(entriesOf(lcm.storage))
 }
 
+// If there is a storage factory, this replaces the storage with a fresh one
+// instead of clearing it in place.
 func (lcm *lockConcurrentMap) Clear() {
 	lcm.mutex.Lock()
 	defer lcm.mutex.Unlock()
-	lcm.storage.Clear()
+
+	if lcm.factory != nil {
+		lcm.storage = lcm.factory()
+	} else {
+		lcm.storage.Clear()
+	}
 }
 
 func (lcm *lockConcurrentMap) Contains(key interface{}) bool {
@@ -53,6 +141,25 @@ func (lcm *lockConcurrentMap) Keys() []interface{} {
 	return lcm.storage.Keys()
 }
 
+func (lcm *lockConcurrentMap) LiveKeys() KeyIterator {
+	lcm.mutex.RLock()
+	defer lcm.mutex.RUnlock()
+
+	if source, ok := lcm.storage.(shardedKeySource); ok {
+		return &liveKeyIterator{lcm: lcm, source: source}
+	}
+
+	return &liveKeyIterator{lcm: lcm, keys: lcm.storage.Keys()}
+}
+
+func (lcm *lockConcurrentMap) SwapStorage(newStorage Map) Map {
+	lcm.mutex.Lock()
+	defer lcm.mutex.Unlock()
+	oldStorage := lcm.storage
+	lcm.storage = newStorage
+	return oldStorage
+}
+
 func (lcm *lockConcurrentMap) Set(key interface{}, value interface{}) (interface{}, bool) {
 	lcm.mutex.Lock()
 	defer lcm.mutex.Unlock()
@@ -60,6 +167,18 @@ func (lcm *lockConcurrentMap) Set(key interface{}, value interface{}) (interface
 }
 
 // NewLockConcurrentMap returns a new lock-based ConcurrentMap.
-func NewLockConcurrentMap(storage Map) Map {
-	return &lockConcurrentMap{mutex: &sync.RWMutex{}, storage: storage}
+func NewLockConcurrentMap(storage Map) LockConcurrentMap {
+	lcm := &lockConcurrentMap{mutex: &sync.RWMutex{}, storage: storage}
+	lcm.concurrentOps = &concurrentOps{accessor: lcm}
+	return lcm
+}
+
+// NewLockConcurrentMapWithFactory returns a new lock-based ConcurrentMap that
+// creates its storage with factory. Clear then replaces the storage with a new
+// one from factory, which suits storages that are cheaper to rebuild than to
+// empty.
+func NewLockConcurrentMapWithFactory(factory func() Map) LockConcurrentMap {
+	lcm := NewLockConcurrentMap(factory()).(*lockConcurrentMap)
+	lcm.factory = factory
+	return lcm
 }
@@ -0,0 +1,198 @@
+package gomap
+
+import (
+	"errors"
+	"testing"
+)
+
+func testMapSwapStorage(t *testing.T, m Map, swap func(Map) Map, oldStorage Map) {
+	/// Setup
+	m.Set("Old", 1)
+	newStorage := NewDefaultBasicMap()
+	newStorage.Set("Existing", 2)
+
+	/// When
+	swapped := swap(newStorage)
+	m.Set("New", 3)
+
+	/// Then
+	if swapped != oldStorage {
+		t.Errorf("Should return previous storage")
+	}
+
+	if value, _ := swapped.Get("Old"); value != 1 || swapped.Length() != 1 {
+		t.Errorf("Previous storage should be intact, but got %v", swapped)
+	}
+
+	if m.Contains("Old") || !m.Contains("Existing") {
+		t.Errorf("Should read from new storage")
+	}
+
+	if value, _ := newStorage.Get("New"); value != 3 {
+		t.Errorf("Should write to new storage")
+	}
+}
+
+func TestLockConcurrentMapSwapStorage(t *testing.T) {
+	storage := NewDefaultBasicMap()
+	lcm := NewLockConcurrentMap(storage)
+	testMapSwapStorage(t, lcm, lcm.SwapStorage, storage)
+}
+
+func TestChannelConcurrentMapSwapStorage(t *testing.T) {
+	storage := NewDefaultBasicMap()
+	ccm := NewChannelConcurrentMap(storage)
+	defer ccm.Close()
+	testMapSwapStorage(t, ccm, ccm.SwapStorage, storage)
+}
+
+func TestLockConcurrentMapWithFactory(t *testing.T) {
+	/// Setup
+	storages := make([]Map, 0)
+
+	lcm := NewLockConcurrentMapWithFactory(func() Map {
+		storage := NewDefaultBasicMap()
+		storages = append(storages, storage)
+		return storage
+	})
+
+	lcm.Set("Key", 1)
+
+	/// When
+	lcm.Clear()
+
+	/// Then
+	if len(storages) != 2 {
+		t.Fatalf("Should create a new storage on Clear, but created %d", len(storages))
+	}
+
+	if storages[0].Length() != 1 {
+		t.Errorf("Should not clear previous storage in place")
+	}
+
+	if current := lcm.SwapStorage(NewDefaultBasicMap()); current != storages[1] || current.Length() != 0 {
+		t.Errorf("Should use fresh storage from factory")
+	}
+}
+
+// A storage whose Length is off by one, to simulate a corrupted count.
+type miscountingMap struct {
+	Map
+}
+
+func (mm *miscountingMap) Length() int {
+	return mm.Map.Length() + 1
+}
+
+func TestLockConcurrentMapVerify(t *testing.T) {
+	/// Setup
+	cm := NewLockConcurrentMap(&miscountingMap{Map: NewDefaultBasicMap()})
+	cm.Set("key", 1)
+
+	/// When
+	err := cm.Verify()
+
+	/// Then
+	if !errors.Is(err, ErrInvariantViolated) {
+		t.Errorf("Should detect a length mismatch, but got %v", err)
+	}
+}
+
+func TestLockConcurrentMapLiveKeys(t *testing.T) {
+	/// Setup
+	cm := NewLockConcurrentMap(NewDefaultBasicMap())
+	keyCount := 1000
+
+	for i := 0; i < keyCount; i++ {
+		cm.Set(i, i)
+	}
+
+	iterator := cm.LiveKeys()
+
+	for i := 0; i < keyCount; i += 2 {
+		cm.Delete(i)
+	}
+
+	mutationDone := make(chan interface{})
+
+	go func() {
+		defer close(mutationDone)
+
+		for i := 1; i < keyCount; i += 4 {
+			cm.Delete(i)
+			cm.Set(keyCount+i, i)
+		}
+	}()
+
+	/// When
+	yielded := make([]interface{}, 0)
+
+	for key, ok := iterator.Next(); ok; key, ok = iterator.Next() {
+		yielded = append(yielded, key)
+	}
+
+	<-mutationDone
+
+	/// Then
+	for _, key := range yielded {
+		if key.(int)%2 == 0 || key.(int) >= keyCount {
+			t.Errorf("Should not yield %v, which was deleted before iteration or added after", key)
+		}
+	}
+
+	if len(yielded) < keyCount/4 {
+		t.Errorf("Should yield at least the keys that are never deleted, but got %d", len(yielded))
+	}
+}
+
+func TestLockConcurrentMapLiveKeysShardedStorage(t *testing.T) {
+	/// Setup
+	storage := NewShardedConcurrentMap(ShardedConcurrentMapParams{ShardCount: 16})
+	cm := NewLockConcurrentMap(storage)
+	keyCount := 1000
+
+	for i := 0; i < keyCount; i++ {
+		cm.Set(i, i)
+	}
+
+	largestShard := 0
+
+	for _, count := range storage.KeyDistribution() {
+		if count > largestShard {
+			largestShard = count
+		}
+	}
+
+	iterator := cm.LiveKeys().(*liveKeyIterator)
+
+	for i := 0; i < keyCount; i += 2 {
+		cm.Delete(i)
+	}
+
+	/// When
+	yielded := make(map[interface{}]bool)
+	largestBatch := 0
+
+	for key, ok := iterator.Next(); ok; key, ok = iterator.Next() {
+		if batch := len(iterator.keys) + 1; batch > largestBatch {
+			largestBatch = batch
+		}
+
+		if yielded[key] {
+			t.Errorf("Should yield %v only once", key)
+		}
+
+		yielded[key] = true
+	}
+
+	/// Then
+	if largestBatch > largestShard {
+		t.Errorf("Should copy at most one shard of keys at a time, but held %d", largestBatch)
+	}
+
+	for i := 0; i < keyCount; i++ {
+		if deleted := i%2 == 0; yielded[i] == deleted {
+			t.Errorf("Should yield exactly the keys that were not deleted, but got %v for %d", yielded[i], i)
+		}
+	}
+}
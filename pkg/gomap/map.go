@@ -1,9 +1,13 @@
 package gomap
 
-// Map represents a key-value storage. Thread-safety is not required.
+// Map represents a key-value storage. Thread-safety is not required. A key may
+// be stored with a nil value, so the boolean results always report whether the
+// key was present rather than whether its value was non-nil.
 type Map interface {
 	Clear()
 	Contains(key interface{}) bool
+
+	// Delete a key, and return the previous value.
 	Delete(key interface{}) (interface{}, bool)
 	Get(key interface{}) (interface{}, bool)
 	Keys() []interface{}
@@ -12,3 +16,9 @@ type Map interface {
 	// Set a key with a value, and return the previous value.
 	Set(key interface{}, value interface{}) (interface{}, bool)
 }
+
+// Entry represents a key-value pair stored in a Map.
+type Entry struct {
+	Key   interface{}
+	Value interface{}
+}
@@ -0,0 +1,94 @@
+package gomap
+
+import (
+	"fmt"
+)
+
+// MapKind identifies one of the Map implementations in this package.
+type MapKind int
+
+// These are the kinds of Map that BuildMap can construct.
+const (
+	BasicMapKind MapKind = iota
+	LockConcurrentMapKind
+	ChannelConcurrentMapKind
+	ShardedConcurrentMapKind
+)
+
+func (kind MapKind) String() string {
+	switch kind {
+	case BasicMapKind:
+		return "BasicMap"
+
+	case LockConcurrentMapKind:
+		return "LockConcurrentMap"
+
+	case ChannelConcurrentMapKind:
+		return "ChannelConcurrentMap"
+
+	case ShardedConcurrentMapKind:
+		return "ShardedConcurrentMap"
+
+	default:
+		return fmt.Sprintf("MapKind(%d)", int(kind))
+	}
+}
+
+// Describe the concrete type of storage, naming the kinds that BuildMap can
+// construct by their MapKind. Sharded storage is described by its shards.
+func storageKindOf(storage Map) string {
+	switch storage := storage.(type) {
+	case *basicMap:
+		return BasicMapKind.String()
+
+	case *lockConcurrentMap:
+		return LockConcurrentMapKind.String()
+
+	case *channelConcurrentMap:
+		return ChannelConcurrentMapKind.String()
+
+	case *shardedConcurrentMap:
+		return ShardedConcurrentMapKind.String()
+
+	case *shardView:
+		if len(storage.shards) > 0 {
+			return storageKindOf(storage.shards[0].storage)
+		}
+	}
+
+	return fmt.Sprintf("%T", storage)
+}
+
+// BuildMap constructs a default Map of the given kind, pre-filled with entries.
+// If entries contain duplicate keys, the last one wins. Concurrent kinds are
+// backed by BasicMap storage. This panics if kind is not recognized.
+//
+// For ChannelConcurrentMapKind, the result runs a loop goroutine that only stops
+// once the map is closed, so callers must type-assert it to
+// ChannelConcurrentMap and call Close when done with it.
+func BuildMap(kind MapKind, entries []Entry) Map {
+	var m Map
+
+	switch kind {
+	case BasicMapKind:
+		m = NewDefaultBasicMap()
+
+	case LockConcurrentMapKind:
+		m = NewLockConcurrentMap(NewDefaultBasicMap())
+
+	case ChannelConcurrentMapKind:
+		m = NewChannelConcurrentMap(NewDefaultBasicMap())
+
+	case ShardedConcurrentMapKind:
+		m = NewDefaultShardedConcurrentMap()
+
+	default:
+		panic(fmt.Sprintf("gomap: unknown %v", kind))
+	}
+
+	for _, entry := range entries {
+		m.Set(entry.Key, entry.Value)
+	}
+
+	return m
+}
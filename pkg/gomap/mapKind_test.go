@@ -0,0 +1,96 @@
+package gomap
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBuildMap(t *testing.T) {
+	/// Setup
+	entries := []Entry{{Key: 1, Value: "a"}, {Key: 2, Value: "b"}, {Key: 1, Value: "c"}}
+
+	kinds := []MapKind{
+		BasicMapKind,
+		LockConcurrentMapKind,
+		ChannelConcurrentMapKind,
+		ShardedConcurrentMapKind,
+	}
+
+	for _, kind := range kinds {
+		/// When
+		m := BuildMap(kind, entries)
+
+		/// Then
+		if length := m.Length(); length != 2 {
+			t.Errorf("%v should have 2 elements, but got %d", kind, length)
+		}
+
+		if value, _ := m.Get(1); value != "c" {
+			t.Errorf("%v should keep last duplicate, but got %v", kind, value)
+		}
+
+		if value, _ := m.Get(2); value != "b" {
+			t.Errorf("%v should contain entry, but got %v", kind, value)
+		}
+
+		if cm, ok := m.(ChannelConcurrentMap); ok {
+			cm.Close()
+		}
+	}
+}
+
+func TestBuildMapUnknownKind(t *testing.T) {
+	/// Setup
+	defer func() {
+		if e := recover(); e == nil {
+			t.Errorf("Should have panicked")
+		}
+	}()
+
+	/// When & Then
+	BuildMap(MapKind(-1), nil)
+}
+
+func TestConcurrentMapStorageKind(t *testing.T) {
+	/// Setup
+	lockStorage := NewLockConcurrentMap(NewDefaultBasicMap())
+	channelStorage := NewChannelConcurrentMap(NewDefaultBasicMap())
+	defer channelStorage.Close()
+	channelMap := NewChannelConcurrentMap(NewDefaultShardedConcurrentMap())
+	defer channelMap.Close()
+	identity := func(key interface{}) interface{} { return key }
+	decoratedMap := NewChannelConcurrentMap(NewDefaultBasicMap(), WithKeyNormalizer(identity), WithOpLog(4), WithDryRun())
+	defer decoratedMap.Close()
+
+	shardedMap := NewShardedConcurrentMap(ShardedConcurrentMapParams{
+		ShardCount: 4,
+		StorageFn:  func() Map { return NewLockConcurrentMap(NewDefaultBasicMap()) },
+	})
+
+	swappedMap := NewLockConcurrentMap(NewDefaultBasicMap())
+	swappedMap.SwapStorage(channelStorage)
+
+	cases := []struct {
+		cm       ConcurrentMap
+		expected string
+	}{
+		{NewLockConcurrentMap(NewDefaultBasicMap()), "BasicMap"},
+		{NewLockConcurrentMap(lockStorage), "LockConcurrentMap"},
+		{channelMap, "ShardedConcurrentMap"},
+		{decoratedMap, "BasicMap"},
+		{NewDefaultShardedConcurrentMap(), "BasicMap"},
+		{shardedMap, "LockConcurrentMap"},
+		{swappedMap, "ChannelConcurrentMap"},
+		{NewLockConcurrentMap(NewFromSyncMap(&sync.Map{})), "*gomap.syncMapAdapter"},
+	}
+
+	for _, c := range cases {
+		/// When
+		kind := c.cm.StorageKind()
+
+		/// Then
+		if kind != c.expected {
+			t.Errorf("Should report %s storage, but got %s", c.expected, kind)
+		}
+	}
+}
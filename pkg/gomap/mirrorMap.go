@@ -0,0 +1,75 @@
+package gomap
+
+import (
+	"fmt"
+	"sync"
+)
+
+type mirrorMap struct {
+	mutex     sync.RWMutex
+	primary   Map
+	secondary Map
+}
+
+func (mm *mirrorMap) String() string {
+	mm.mutex.RLock()
+	defer mm.mutex.RUnlock()
+	return fmt.Sprint(mm.primary)
+}
+
+func (mm *mirrorMap) Clear() {
+	mm.mutex.Lock()
+	defer mm.mutex.Unlock()
+	mm.secondary.Clear()
+	mm.primary.Clear()
+}
+
+func (mm *mirrorMap) Contains(key interface{}) bool {
+	mm.mutex.RLock()
+	defer mm.mutex.RUnlock()
+	return mm.primary.Contains(key)
+}
+
+func (mm *mirrorMap) Delete(key interface{}) (interface{}, bool) {
+	mm.mutex.Lock()
+	defer mm.mutex.Unlock()
+	mm.secondary.Delete(key)
+	return mm.primary.Delete(key)
+}
+
+func (mm *mirrorMap) Get(key interface{}) (interface{}, bool) {
+	mm.mutex.RLock()
+	defer mm.mutex.RUnlock()
+	return mm.primary.Get(key)
+}
+
+func (mm *mirrorMap) Length() int {
+	mm.mutex.RLock()
+	defer mm.mutex.RUnlock()
+	return mm.primary.Length()
+}
+
+func (mm *mirrorMap) Keys() []interface{} {
+	mm.mutex.RLock()
+	defer mm.mutex.RUnlock()
+	return mm.primary.Keys()
+}
+
+func (mm *mirrorMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	mm.mutex.Lock()
+	defer mm.mutex.Unlock()
+	mm.secondary.Set(key, value)
+	return mm.primary.Set(key, value)
+}
+
+// NewMirrorMap returns a new Map that applies every mutation to both primary
+// and secondary, and serves reads from primary. Mutations hold a write lock, so
+// reads never observe a mutation that has not been applied to both maps.
+//
+// The secondary is always written first: if it panics (e.g. because it is a
+// closed ChannelConcurrentMap), the panic propagates to the caller and the
+// primary is left untouched, so the two maps only diverge if the primary itself
+// fails after the secondary succeeded.
+func NewMirrorMap(primary Map, secondary Map) Map {
+	return &mirrorMap{primary: primary, secondary: secondary}
+}
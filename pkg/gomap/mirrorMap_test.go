@@ -0,0 +1,63 @@
+package gomap
+
+import (
+	"testing"
+)
+
+func TestMirrorMapReflectsMutations(t *testing.T) {
+	/// Setup
+	primary := NewDefaultBasicMap()
+	secondary := NewDefaultBasicMap()
+	mm := NewMirrorMap(primary, secondary)
+
+	/// When
+	mm.Set(1, 1)
+	mm.Set(2, 2)
+	mm.Set(3, 3)
+	mm.Delete(2)
+
+	/// Then
+	for _, storage := range []Map{primary, secondary} {
+		if length := storage.Length(); length != 2 {
+			t.Errorf("Should have 2 elements, but got %d", length)
+		}
+
+		if value, found := storage.Get(3); !found || value != 3 {
+			t.Errorf("Should contain mirrored value")
+		}
+
+		if storage.Contains(2) {
+			t.Errorf("Should have mirrored delete")
+		}
+	}
+
+	mm.Clear()
+
+	if primary.Length() != 0 || secondary.Length() != 0 {
+		t.Errorf("Should have mirrored clear")
+	}
+}
+
+func TestMirrorMapSecondaryFailure(t *testing.T) {
+	/// Setup
+	primary := NewDefaultBasicMap()
+	secondary := NewChannelConcurrentMap(NewDefaultBasicMap())
+	mm := NewMirrorMap(primary, secondary)
+	secondary.Close()
+
+	/// When
+	func() {
+		defer func() {
+			if e := recover(); e == nil {
+				t.Errorf("Should have panicked")
+			}
+		}()
+
+		mm.Set("Key", "Value")
+	}()
+
+	/// Then
+	if primary.Contains("Key") {
+		t.Errorf("Should not write primary after secondary failure")
+	}
+}
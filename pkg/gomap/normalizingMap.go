@@ -0,0 +1,44 @@
+package gomap
+
+import (
+	"fmt"
+)
+
+// Since every write goes through normalize, the keys of storage are already in
+// canonical form.
+type normalizingMap struct {
+	storage   Map
+	normalize func(key interface{}) interface{}
+}
+
+func (nm *normalizingMap) String() string {
+	return fmt.Sprint(nm.storage)
+}
+
+func (nm *normalizingMap) Clear() {
+	nm.storage.Clear()
+}
+
+func (nm *normalizingMap) Contains(key interface{}) bool {
+	return nm.storage.Contains(nm.normalize(key))
+}
+
+func (nm *normalizingMap) Delete(key interface{}) (interface{}, bool) {
+	return nm.storage.Delete(nm.normalize(key))
+}
+
+func (nm *normalizingMap) Get(key interface{}) (interface{}, bool) {
+	return nm.storage.Get(nm.normalize(key))
+}
+
+func (nm *normalizingMap) Length() int {
+	return nm.storage.Length()
+}
+
+func (nm *normalizingMap) Keys() []interface{} {
+	return nm.storage.Keys()
+}
+
+func (nm *normalizingMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	return nm.storage.Set(nm.normalize(key), value)
+}
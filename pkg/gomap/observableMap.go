@@ -0,0 +1,248 @@
+package gomap
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// ObservableMap represents a Map whose keys can be watched for new values.
+type ObservableMap interface {
+	Map
+
+	// WatchKey streams every value subsequently Set for key, starting with the
+	// current value if the key is present. Values are delivered in the order
+	// they were Set, and slow consumers do not block writers. Call the returned
+	// func to unsubscribe, which closes the channel.
+	WatchKey(key interface{}) (<-chan interface{}, func())
+
+	// SetIfChanged sets key to value and notifies watchers, unless key is
+	// already present with a value that is reflect.DeepEqual to it. It reports
+	// whether it wrote.
+	SetIfChanged(key interface{}, value interface{}) bool
+
+	// Listeners returns the number of watchers that have not unsubscribed yet.
+	// Since every watcher keeps a goroutine alive, a count that keeps growing
+	// points to a subscription leak.
+	Listeners() int
+
+	// ListenerSites describes where each active watcher was created, as
+	// "file:line" of the WatchKey caller, to help track down leaks.
+	ListenerSites() []string
+}
+
+// Each watcher has its own dispatch goroutine that drains an unbounded queue of
+// pending values into the watch channel.
+type keyWatcher struct {
+	site    string
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	pending []interface{}
+	stopped bool
+	stopCh  chan interface{}
+	valueCh chan interface{}
+}
+
+func (w *keyWatcher) push(value interface{}) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.pending = append(w.pending, value)
+	w.cond.Signal()
+}
+
+func (w *keyWatcher) stop() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.stopped {
+		w.stopped = true
+		close(w.stopCh)
+		w.cond.Signal()
+	}
+}
+
+func (w *keyWatcher) dispatch() {
+	defer close(w.valueCh)
+
+	for {
+		w.mutex.Lock()
+
+		for len(w.pending) == 0 && !w.stopped {
+			w.cond.Wait()
+		}
+
+		if w.stopped {
+			w.mutex.Unlock()
+			return
+		}
+
+		value := w.pending[0]
+		w.pending = w.pending[1:]
+		w.mutex.Unlock()
+
+		select {
+		case w.valueCh <- value:
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func newKeyWatcher(site string) *keyWatcher {
+	w := &keyWatcher{
+		site:    site,
+		stopCh:  make(chan interface{}),
+		valueCh: make(chan interface{}),
+	}
+
+	w.cond = sync.NewCond(&w.mutex)
+	go w.dispatch()
+	return w
+}
+
+type observableMap struct {
+	mutex    sync.Mutex
+	storage  Map
+	watchers map[interface{}]map[*keyWatcher]bool
+}
+
+func (om *observableMap) String() string {
+	return fmt.Sprint(om.storage)
+}
+
+func (om *observableMap) Clear() {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+	om.storage.Clear()
+}
+
+func (om *observableMap) Contains(key interface{}) bool {
+	return om.storage.Contains(key)
+}
+
+func (om *observableMap) Delete(key interface{}) (interface{}, bool) {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+	return om.storage.Delete(key)
+}
+
+func (om *observableMap) Get(key interface{}) (interface{}, bool) {
+	return om.storage.Get(key)
+}
+
+func (om *observableMap) Length() int {
+	return om.storage.Length()
+}
+
+func (om *observableMap) Keys() []interface{} {
+	return om.storage.Keys()
+}
+
+// Writes are serialized with their notifications so that watchers receive
+// values in the order they were Set.
+func (om *observableMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+	prev, found := om.storage.Set(key, value)
+
+	for watcher := range om.watchers[key] {
+		watcher.push(value)
+	}
+
+	return prev, found
+}
+
+func (om *observableMap) SetIfChanged(key interface{}, value interface{}) bool {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+
+	if existing, found := om.storage.Get(key); found && reflect.DeepEqual(existing, value) {
+		return false
+	}
+
+	om.storage.Set(key, value)
+
+	for watcher := range om.watchers[key] {
+		watcher.push(value)
+	}
+
+	return true
+}
+
+func (om *observableMap) WatchKey(key interface{}) (<-chan interface{}, func()) {
+	site := "unknown"
+
+	if _, file, line, ok := runtime.Caller(1); ok {
+		site = fmt.Sprintf("%s:%d", file, line)
+	}
+
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+	watcher := newKeyWatcher(site)
+
+	if value, found := om.storage.Get(key); found {
+		watcher.push(value)
+	}
+
+	if _, found := om.watchers[key]; !found {
+		om.watchers[key] = make(map[*keyWatcher]bool)
+	}
+
+	om.watchers[key][watcher] = true
+	once := sync.Once{}
+
+	unsubscribe := func() {
+		once.Do(func() {
+			om.mutex.Lock()
+			defer om.mutex.Unlock()
+			delete(om.watchers[key], watcher)
+
+			if len(om.watchers[key]) == 0 {
+				delete(om.watchers, key)
+			}
+
+			watcher.stop()
+		})
+	}
+
+	return watcher.valueCh, unsubscribe
+}
+
+func (om *observableMap) Listeners() int {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+	listeners := 0
+
+	for _, watchers := range om.watchers {
+		listeners += len(watchers)
+	}
+
+	return listeners
+}
+
+func (om *observableMap) ListenerSites() []string {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+	sites := make([]string, 0)
+
+	for _, watchers := range om.watchers {
+		for watcher := range watchers {
+			sites = append(sites, watcher.site)
+		}
+	}
+
+	sort.Strings(sites)
+	return sites
+}
+
+// NewObservableMap returns a new ObservableMap that notifies watchers of values
+// Set on storage. The storage must be a ConcurrentMap if the ObservableMap is
+// accessed from several goroutines.
+func NewObservableMap(storage Map) ObservableMap {
+	return &observableMap{
+		storage:  storage,
+		watchers: make(map[interface{}]map[*keyWatcher]bool),
+	}
+}
@@ -0,0 +1,112 @@
+package gomap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObservableMapWatchKey(t *testing.T) {
+	/// Setup
+	storage := NewLockConcurrentMap(NewDefaultBasicMap())
+	om := NewObservableMap(storage)
+	key := "Key"
+	om.Set(key, 0)
+	om.Set("Other", -1)
+
+	/// When
+	valueCh, unsubscribe := om.WatchKey(key)
+
+	for i := 1; i <= 5; i++ {
+		om.Set(key, i)
+	}
+
+	/// Then
+	for i := 0; i <= 5; i++ {
+		select {
+		case value := <-valueCh:
+			if value != i {
+				t.Errorf("Should receive %d, but got %v", i, value)
+			}
+
+		case <-time.After(time.Second):
+			t.Fatalf("Should have received %d", i)
+		}
+	}
+
+	unsubscribe()
+	unsubscribe()
+
+	select {
+	case _, ok := <-valueCh:
+		if ok {
+			t.Errorf("Should have closed channel")
+		}
+
+	case <-time.After(time.Second):
+		t.Errorf("Should have closed channel")
+	}
+}
+
+func TestObservableMapListeners(t *testing.T) {
+	/// Setup
+	om := NewObservableMap(NewDefaultBasicMap())
+	unsubscribes := make([]func(), 0)
+
+	/// When
+	for i := 0; i < 3; i++ {
+		_, unsubscribe := om.WatchKey(i % 2)
+		unsubscribes = append(unsubscribes, unsubscribe)
+	}
+
+	/// Then
+	if listeners := om.Listeners(); listeners != 3 {
+		t.Errorf("Should count active watchers, but got %d", listeners)
+	}
+
+	for _, site := range om.ListenerSites() {
+		if !strings.Contains(site, "observableMap_test.go:") {
+			t.Errorf("Should record where watcher was created, but got %s", site)
+		}
+	}
+
+	for _, unsubscribe := range unsubscribes {
+		unsubscribe()
+		unsubscribe()
+	}
+
+	if listeners := om.Listeners(); listeners != 0 || len(om.ListenerSites()) != 0 {
+		t.Errorf("Should return to zero after unsubscribing, but got %d", listeners)
+	}
+}
+
+func TestObservableMapSetIfChanged(t *testing.T) {
+	/// Setup
+	om := NewObservableMap(NewDefaultBasicMap())
+	valueCh, unsubscribe := om.WatchKey("Key")
+	defer unsubscribe()
+
+	/// When
+	changed := []bool{
+		om.SetIfChanged("Key", 1),
+		om.SetIfChanged("Key", 1),
+		om.SetIfChanged("Key", 2),
+	}
+
+	/// Then
+	if !changed[0] || changed[1] || !changed[2] {
+		t.Errorf("Should only write changed values, but got %v", changed)
+	}
+
+	for _, expected := range []int{1, 2} {
+		select {
+		case value := <-valueCh:
+			if value != expected {
+				t.Errorf("Should not notify identical re-set, but got %v", value)
+			}
+
+		case <-time.After(time.Second):
+			t.Fatalf("Should have received %d", expected)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package gomap
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// OpRecord describes a single operation recorded by WithOpLog. Values are not
+// recorded, so that the log neither retains nor exposes them.
+type OpRecord struct {
+	// Op is one of "Clear", "Delete", "Get" and "Set".
+	Op   string
+	Key  interface{}
+	Time time.Time
+}
+
+// Ring buffer of the most recent operations. It is written on the loop
+// goroutine but read by RecentOps, hence the mutex.
+type opLog struct {
+	mutex   sync.Mutex
+	clock   clock
+	records []OpRecord
+	next    int
+	full    bool
+}
+
+func (log *opLog) record(op string, key interface{}) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	log.records[log.next] = OpRecord{Op: op, Key: key, Time: log.clock.Now()}
+	log.next = (log.next + 1) % len(log.records)
+	log.full = log.full || log.next == 0
+}
+
+// Return the records from oldest to newest.
+func (log *opLog) recent() []OpRecord {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	if !log.full {
+		return append([]OpRecord(nil), log.records[:log.next]...)
+	}
+
+	return append(append([]OpRecord(nil), log.records[log.next:]...), log.records[:log.next]...)
+}
+
+func newOpLog(size int, clock clock) *opLog {
+	return &opLog{clock: clock, records: make([]OpRecord, size)}
+}
+
+// This records every Clear, Delete, Get and Set that reaches storage.
+type loggingMap struct {
+	storage Map
+	log     *opLog
+}
+
+func (lm *loggingMap) String() string {
+	return fmt.Sprint(lm.storage)
+}
+
+func (lm *loggingMap) Clear() {
+	lm.log.record("Clear", nil)
+	lm.storage.Clear()
+}
+
+func (lm *loggingMap) Contains(key interface{}) bool {
+	return lm.storage.Contains(key)
+}
+
+func (lm *loggingMap) Delete(key interface{}) (interface{}, bool) {
+	lm.log.record("Delete", key)
+	return lm.storage.Delete(key)
+}
+
+func (lm *loggingMap) Get(key interface{}) (interface{}, bool) {
+	lm.log.record("Get", key)
+	return lm.storage.Get(key)
+}
+
+func (lm *loggingMap) Length() int {
+	return lm.storage.Length()
+}
+
+func (lm *loggingMap) Keys() []interface{} {
+	return lm.storage.Keys()
+}
+
+func (lm *loggingMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	lm.log.record("Set", key)
+	return lm.storage.Set(key, value)
+}
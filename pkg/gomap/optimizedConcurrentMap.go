@@ -0,0 +1,57 @@
+package gomap
+
+// Workload describes the expected access pattern of a ConcurrentMap.
+type Workload struct {
+	// ReadRatio is the expected fraction of operations that are reads, between
+	// 0 and 1.
+	ReadRatio float64
+
+	// Concurrency is the expected number of goroutines accessing the map at the
+	// same time.
+	Concurrency uint
+
+	// ArrivalOrder asks for operations to be applied in the order they are
+	// issued. Locks make no such promise, while the loop goroutine of a
+	// ChannelConcurrentMap handles requests first come, first served.
+	ArrivalOrder bool
+}
+
+const (
+	shardingConcurrency = 16
+	lockReadRatio       = 0.5
+)
+
+// NewOptimizedConcurrentMap returns the ConcurrentMap implementation that best
+// suits a Workload:
+//
+// - ChannelConcurrentMap if ArrivalOrder is set, since it is the only one that
+// applies operations in arrival order. Its loop goroutine runs until it is
+// closed, so callers must type-assert the result to ChannelConcurrentMap and
+// call Close when done with it.
+//
+// - Sharded ConcurrentMap if Concurrency is at least 16, with one shard per
+// expected goroutine, because a single lock becomes the bottleneck.
+//
+// - LockConcurrentMap if ReadRatio is at least 0.5, because readers can hold
+// the read lock at the same time, or if Concurrency is at most 1.
+//
+// - Sharded ConcurrentMap otherwise, with one shard per expected goroutine,
+// since concurrent writers would all wait on a single lock.
+//
+// Only the ArrivalOrder rule selects ChannelConcurrentMap, because for every
+// other workload LockConcurrentMap is faster and needs no Close.
+func NewOptimizedConcurrentMap(hint Workload) ConcurrentMap {
+	switch {
+	case hint.ArrivalOrder:
+		return NewChannelConcurrentMap(NewDefaultBasicMap())
+
+	case hint.Concurrency >= shardingConcurrency,
+		hint.ReadRatio < lockReadRatio && hint.Concurrency > 1:
+		return NewShardedConcurrentMap(ShardedConcurrentMapParams{
+			ShardCount: hint.Concurrency,
+		})
+
+	default:
+		return NewLockConcurrentMap(NewDefaultBasicMap())
+	}
+}
@@ -0,0 +1,43 @@
+package gomap
+
+import (
+	"testing"
+)
+
+func TestOptimizedConcurrentMapSelection(t *testing.T) {
+	/// Setup
+	hints := []struct {
+		hint        Workload
+		shardCount  int
+		description string
+	}{
+		{Workload{ReadRatio: 0.9, Concurrency: 32}, 32, "high concurrency"},
+		{Workload{ReadRatio: 0.9, Concurrency: 4}, 0, "read-heavy"},
+		{Workload{ReadRatio: 0.1, Concurrency: 4}, 4, "concurrent writes"},
+		{Workload{ReadRatio: 0.1, Concurrency: 1}, 0, "single writer"},
+	}
+
+	for _, hint := range hints {
+		/// When
+		cm := NewOptimizedConcurrentMap(hint.hint)
+
+		/// Then
+		if hint.shardCount == 0 {
+			if _, ok := cm.(*lockConcurrentMap); !ok {
+				t.Errorf("Should have selected lock map for %s, but got %T", hint.description, cm)
+			}
+		} else if scm, ok := cm.(*shardedConcurrentMap); !ok {
+			t.Errorf("Should have selected sharded map for %s, but got %T", hint.description, cm)
+		} else if len(scm.view.shards) != hint.shardCount {
+			t.Errorf("Should have %d shards for %s, but got %d", hint.shardCount, hint.description, len(scm.view.shards))
+		}
+	}
+
+	ordered := NewOptimizedConcurrentMap(Workload{ReadRatio: 0.1, Concurrency: 32, ArrivalOrder: true})
+
+	if ccm, ok := ordered.(*channelConcurrentMap); !ok {
+		t.Errorf("Should have selected channel map for arrival order, but got %T", ordered)
+	} else {
+		ccm.Close()
+	}
+}
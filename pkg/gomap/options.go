@@ -0,0 +1,140 @@
+package gomap
+
+import (
+	"time"
+)
+
+// Option configures a ChannelConcurrentMap.
+type Option func(*channelConcurrentMap)
+
+// WithBatchDrain lets the loop goroutine handle up to max more requests that
+// are already queued after each one it receives, before waiting on the request
+// channel again. This amortizes scheduling overhead when the map is saturated.
+func WithBatchDrain(max int) Option {
+	return func(ccm *channelConcurrentMap) {
+		ccm.batchDrain = max
+	}
+}
+
+// WithDryRun makes Set, Delete and Clear leave the storage untouched, while
+// still validating keys and returning what they would have returned, e.g. the
+// previous value for Set. Compound operations are affected too, so they may
+// not see their own writes. This is meant for rehearsing migrations.
+func WithDryRun() Option {
+	return func(ccm *channelConcurrentMap) {
+		ccm.decorators = append(ccm.decorators, func(storage Map) Map {
+			return &dryRunMap{storage: storage}
+		})
+	}
+}
+
+// WithKeyNormalizer canonicalizes every key with fn before it reaches the
+// storage, e.g. lowercasing strings so that "Foo" and "foo" refer to the same
+// entry. Keys returns the normalized keys.
+func WithKeyNormalizer(fn func(key interface{}) interface{}) Option {
+	return func(ccm *channelConcurrentMap) {
+		ccm.decorators = append(ccm.decorators, func(storage Map) Map {
+			return &normalizingMap{storage: storage, normalize: fn}
+		})
+	}
+}
+
+// WithKeyValidation checks that keys are comparable before they are sent to the
+// loop goroutine, so that slice or map keys are reported with an error wrapping
+// ErrInvalidKey instead of crashing the loop with a runtime panic. TrySet and
+// TryDelete return the error, while the other methods panic with it on the
+// calling goroutine. Validation uses reflection, so it is off by default.
+func WithKeyValidation() Option {
+	return func(ccm *channelConcurrentMap) {
+		ccm.validateKeys = true
+	}
+}
+
+// WithMaxInFlight caps the number of requests that are queued for or being
+// processed by the loop goroutine at n, so that pending work cannot pile up
+// without bound under overload. Callers block until a slot frees up, except
+// for Ping and calls with a response timeout, which give up once it fires.
+func WithMaxInFlight(n int) Option {
+	return func(ccm *channelConcurrentMap) {
+		if n > 0 {
+			ccm.inFlight = make(chan interface{}, n)
+		}
+	}
+}
+
+// WithOpLog records the last size Clear, Delete, Get and Set operations that
+// reach the storage, including those made by compound operations, so that
+// RecentOps can show how a key got its value. Keys are recorded as they are
+// passed to the storage, after any decorator installed by an earlier option.
+func WithOpLog(size int) Option {
+	return func(ccm *channelConcurrentMap) {
+		if size <= 0 {
+			return
+		}
+
+		log := newOpLog(size, systemClock{})
+		ccm.opLog = log
+
+		ccm.decorators = append(ccm.decorators, func(storage Map) Map {
+			return &loggingMap{storage: storage, log: log}
+		})
+	}
+}
+
+// WithPanicHandler sets a callback that receives the value recovered whenever
+// handling a request panics, e.g. so that the application can log or alert on
+// it. The panic is still raised again on the caller's goroutine, and the loop
+// goroutine goes on serving other requests. The handler also receives an error
+// wrapping ErrUnknownRequest for every request of an unrecognized type, which is
+// dropped. The handler runs on the loop goroutine, and panics raised by the
+// handler are ignored. Defaults to a no-op.
+func WithPanicHandler(fn func(recovered interface{})) Option {
+	return func(ccm *channelConcurrentMap) {
+		ccm.panicHandler = fn
+	}
+}
+
+// WithRequestBuffer sets the capacity of the channel that queues requests for
+// the loop goroutine. A larger buffer lets callers enqueue without waiting for
+// the loop, while 0 makes every send wait for the loop to receive it. Defaults
+// to 1.
+func WithRequestBuffer(size int) Option {
+	return func(ccm *channelConcurrentMap) {
+		if size >= 0 {
+			ccm.requestBuffer = size
+		}
+	}
+}
+
+// WithResponseTimeout bounds how long an operation waits for the loop goroutine
+// to accept its request and reply to it, so that a stuck loop surfaces as
+// ErrLoopUnresponsive instead of blocking the caller, and Close, forever. Try
+// variants return the error, while the other methods panic with it. Defaults to
+// no timeout.
+func WithResponseTimeout(timeout time.Duration) Option {
+	return func(ccm *channelConcurrentMap) {
+		ccm.responseTimeout = timeout
+	}
+}
+
+// WithStringLimit makes String print at most n entries, followed by the number
+// of entries left out, so that logging a huge map does not flood the logs.
+// FullString still prints every entry.
+func WithStringLimit(n int) Option {
+	return func(ccm *channelConcurrentMap) {
+		ccm.stringLimit = n
+	}
+}
+
+// WithWriteRateLimit limits Set operations to perSecond per second, with bursts
+// of up to perSecond writes. Throttling happens on the calling goroutine before
+// the request reaches the loop goroutine, so a runaway producer cannot starve
+// reads: Set blocks until the write is allowed, while TrySet returns
+// ErrRateLimited immediately.
+func WithWriteRateLimit(perSecond int) Option {
+	return func(ccm *channelConcurrentMap) {
+		if perSecond > 0 {
+			ccm.writeLimiter = newRateLimiter(perSecond)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package gomap
+
+import (
+	"sync"
+	"time"
+)
+
+// Token bucket that refills at perSecond tokens per second, holding at most
+// perSecond tokens.
+type rateLimiter struct {
+	mutex     sync.Mutex
+	perSecond float64
+	tokens    float64
+	last      time.Time
+}
+
+func (rl *rateLimiter) refill() {
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.perSecond
+	rl.last = now
+
+	if rl.tokens > rl.perSecond {
+		rl.tokens = rl.perSecond
+	}
+}
+
+// Take a token if one is available, or return how long until one will be.
+func (rl *rateLimiter) reserve() (time.Duration, bool) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	rl.refill()
+
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return 0, true
+	}
+
+	return time.Duration((1 - rl.tokens) / rl.perSecond * float64(time.Second)), false
+}
+
+func (rl *rateLimiter) tryTake() bool {
+	_, ok := rl.reserve()
+	return ok
+}
+
+func (rl *rateLimiter) take() {
+	for {
+		wait, ok := rl.reserve()
+
+		if ok {
+			return
+		}
+
+		time.Sleep(wait)
+	}
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	return &rateLimiter{
+		perSecond: float64(perSecond),
+		tokens:    float64(perSecond),
+		last:      time.Now(),
+	}
+}
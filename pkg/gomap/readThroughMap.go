@@ -0,0 +1,249 @@
+package gomap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ReadThroughMap represents a Map that loads missing values on demand and
+// caches them. Only Get and Load consult the loader; the other methods operate
+// on the cache alone.
+type ReadThroughMap interface {
+	Map
+
+	// Load returns the cached value for key, or calls the loader on a miss and
+	// caches the value if it is found. Concurrent misses for the same key share
+	// a single loader call. Loader errors are returned and are not cached. If the
+	// loader panics, the caller that started the call panics too, and the callers
+	// sharing it get an error wrapping ErrLoaderPanicked.
+	Load(key interface{}) (interface{}, bool, error)
+
+	// LoadContext behaves like Load, and passes ctx to a loader set with
+	// WithContextLoader, e.g. to carry trace IDs or deadlines. When concurrent
+	// misses share a loader call, the loader receives the ctx of the caller that
+	// started it.
+	LoadContext(ctx context.Context, key interface{}) (interface{}, bool, error)
+
+	// GetMetered behaves like Get, and also reports how long the lookup took,
+	// including any loader call. This tells cache hits apart from misses when
+	// tuning the cache.
+	GetMetered(key interface{}) (value interface{}, found bool, latency time.Duration)
+}
+
+// ReadThroughOption configures a ReadThroughMap.
+type ReadThroughOption func(*readThroughMap)
+
+// WithNegativeCacheTTL remembers keys that the loader reported as not found for
+// ttl, so that repeated lookups of an absent key do not call the loader again
+// until ttl has elapsed. Setting a key discards its negative entry.
+func WithNegativeCacheTTL(ttl time.Duration) ReadThroughOption {
+	return func(rtm *readThroughMap) {
+		rtm.negativeTTL = ttl
+	}
+}
+
+// WithContextLoader replaces the loader passed to the constructor with one that
+// receives the context given to LoadContext. Load and Get pass
+// context.Background().
+func WithContextLoader(loader func(ctx context.Context, key interface{}) (interface{}, bool, error)) ReadThroughOption {
+	return func(rtm *readThroughMap) {
+		rtm.loader = loader
+	}
+}
+
+type loadCall struct {
+	waitGroup sync.WaitGroup
+	value     interface{}
+	found     bool
+	err       error
+}
+
+// A load is made stale by a write to its key while the loader is running, in
+// which case its result is returned to the callers waiting on it, but is not
+// cached.
+type readThroughCall struct {
+	loadCall
+	stale bool
+}
+
+// Negatively cached keys are kept in misses with their expiry times, apart from
+// the cache, so that they never show up as entries. Writes to the cache hold
+// mutex, so that a load cannot overwrite a write made while it was running.
+type readThroughMap struct {
+	mutex       sync.Mutex
+	cache       Map
+	loader      func(ctx context.Context, key interface{}) (interface{}, bool, error)
+	clock       clock
+	calls       map[interface{}]*readThroughCall
+	misses      map[interface{}]time.Time
+	negativeTTL time.Duration
+}
+
+func (rtm *readThroughMap) String() string {
+	return fmt.Sprint(rtm.cache)
+}
+
+func (rtm *readThroughMap) Clear() {
+	rtm.mutex.Lock()
+	defer rtm.mutex.Unlock()
+	rtm.misses = make(map[interface{}]time.Time)
+
+	for _, call := range rtm.calls {
+		call.stale = true
+	}
+
+	rtm.cache.Clear()
+}
+
+func (rtm *readThroughMap) Contains(key interface{}) bool {
+	return rtm.cache.Contains(key)
+}
+
+func (rtm *readThroughMap) Delete(key interface{}) (interface{}, bool) {
+	rtm.mutex.Lock()
+	defer rtm.mutex.Unlock()
+	rtm.invalidateLoad(key)
+	return rtm.cache.Delete(key)
+}
+
+// Loader errors are reported as a miss. Use Load to inspect them.
+func (rtm *readThroughMap) Get(key interface{}) (interface{}, bool) {
+	value, found, _ := rtm.Load(key)
+	return value, found
+}
+
+func (rtm *readThroughMap) GetMetered(key interface{}) (interface{}, bool, time.Duration) {
+	start := rtm.clock.Now()
+	value, found := rtm.Get(key)
+	return value, found, rtm.clock.Now().Sub(start)
+}
+
+func (rtm *readThroughMap) Length() int {
+	return rtm.cache.Length()
+}
+
+func (rtm *readThroughMap) Keys() []interface{} {
+	return rtm.cache.Keys()
+}
+
+func (rtm *readThroughMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	rtm.mutex.Lock()
+	defer rtm.mutex.Unlock()
+	delete(rtm.misses, key)
+	rtm.invalidateLoad(key)
+	return rtm.cache.Set(key, value)
+}
+
+// This must be called with mutex held.
+func (rtm *readThroughMap) invalidateLoad(key interface{}) {
+	if call, ok := rtm.calls[key]; ok {
+		call.stale = true
+	}
+}
+
+func (rtm *readThroughMap) Load(key interface{}) (interface{}, bool, error) {
+	return rtm.LoadContext(context.Background(), key)
+}
+
+func (rtm *readThroughMap) LoadContext(ctx context.Context, key interface{}) (interface{}, bool, error) {
+	if value, found := rtm.cache.Get(key); found {
+		return value, found, nil
+	}
+
+	rtm.mutex.Lock()
+
+	if call, ok := rtm.calls[key]; ok {
+		rtm.mutex.Unlock()
+		call.waitGroup.Wait()
+		return call.value, call.found, call.err
+	}
+
+	// Check again, since a call for key may have completed since the first
+	// check.
+	if value, found := rtm.cache.Get(key); found {
+		rtm.mutex.Unlock()
+		return value, found, nil
+	}
+
+	if expiry, found := rtm.misses[key]; found {
+		if rtm.clock.Now().Before(expiry) {
+			rtm.mutex.Unlock()
+			return nil, false, nil
+		}
+
+		delete(rtm.misses, key)
+	}
+
+	call := &readThroughCall{}
+	call.waitGroup.Add(1)
+	rtm.calls[key] = call
+	rtm.mutex.Unlock()
+	rtm.runLoad(ctx, key, call)
+	return call.value, call.found, call.err
+}
+
+// The cleanup is deferred, so that a panicking loader does not leave callers
+// that are waiting on call, or that load key later, blocked forever. A panic is
+// recorded as the error of call before it is propagated, so that waiters do not
+// mistake it for a miss.
+func (rtm *readThroughMap) runLoad(ctx context.Context, key interface{}, call *readThroughCall) {
+	defer func() {
+		recovered := recover()
+
+		if recovered != nil {
+			call.value, call.found = nil, false
+			call.err = fmt.Errorf("%w: %v", ErrLoaderPanicked, recovered)
+		}
+
+		rtm.mutex.Lock()
+		delete(rtm.calls, key)
+		rtm.mutex.Unlock()
+		call.waitGroup.Done()
+
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+
+	call.value, call.found, call.err = rtm.loader(ctx, key)
+	rtm.mutex.Lock()
+	defer rtm.mutex.Unlock()
+
+	if call.err != nil || call.stale {
+		return
+	}
+
+	if call.found {
+		rtm.cache.Set(key, call.value)
+	} else if rtm.negativeTTL > 0 {
+		rtm.misses[key] = rtm.clock.Now().Add(rtm.negativeTTL)
+	}
+}
+
+func newReadThroughMap(cache Map, loader func(key interface{}) (interface{}, bool, error), clock clock, options ...ReadThroughOption) *readThroughMap {
+	rtm := &readThroughMap{
+		cache: cache,
+		loader: func(ctx context.Context, key interface{}) (interface{}, bool, error) {
+			return loader(key)
+		},
+		clock:  clock,
+		calls:  make(map[interface{}]*readThroughCall),
+		misses: make(map[interface{}]time.Time),
+	}
+
+	for _, option := range options {
+		option(rtm)
+	}
+
+	return rtm
+}
+
+// NewReadThroughMap returns a new ReadThroughMap that caches the values
+// returned by loader in cache. loader may be nil if WithContextLoader is given.
+// cache must be goroutine-safe if the map is used
+// concurrently.
+func NewReadThroughMap(cache Map, loader func(key interface{}) (interface{}, bool, error), options ...ReadThroughOption) ReadThroughMap {
+	return newReadThroughMap(cache, loader, systemClock{}, options...)
+}
@@ -0,0 +1,317 @@
+package gomap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadThroughMapCacheHit(t *testing.T) {
+	/// Setup
+	loads := int32(0)
+
+	rtm := NewReadThroughMap(NewDefaultBasicMap(), func(key interface{}) (interface{}, bool, error) {
+		atomic.AddInt32(&loads, 1)
+		return key.(int) * 10, key.(int) > 0, nil
+	})
+
+	/// When
+	first, found1 := rtm.Get(1)
+	second, found2 := rtm.Get(1)
+	_, found3 := rtm.Get(-1)
+
+	/// Then
+	if first != 10 || second != 10 || !found1 || !found2 {
+		t.Errorf("Should return loaded value")
+	}
+
+	if found3 || rtm.Contains(-1) {
+		t.Errorf("Should not cache missing key")
+	}
+
+	if loads != 2 {
+		t.Errorf("Should skip loader on cache hit, but got %d loads", loads)
+	}
+}
+
+func TestReadThroughMapLoaderError(t *testing.T) {
+	/// Setup
+	loadErr := errors.New("Load failure")
+	fail := true
+
+	rtm := NewReadThroughMap(NewDefaultBasicMap(), func(key interface{}) (interface{}, bool, error) {
+		if fail {
+			return nil, false, loadErr
+		}
+
+		return 1, true, nil
+	})
+
+	/// When & Then
+	if _, found, err := rtm.Load(1); found || err != loadErr {
+		t.Errorf("Should propagate loader error, but got %v", err)
+	}
+
+	if rtm.Length() != 0 {
+		t.Errorf("Should not cache errors")
+	}
+
+	fail = false
+
+	if value, found, err := rtm.Load(1); value != 1 || !found || err != nil {
+		t.Errorf("Should retry loader after error")
+	}
+}
+
+func TestReadThroughMapSingleFlight(t *testing.T) {
+	/// Setup
+	keyCount := 10
+	callerCount := 20
+	loads := make([]int32, keyCount)
+
+	rtm := NewReadThroughMap(NewLockConcurrentMap(NewDefaultBasicMap()), func(key interface{}) (interface{}, bool, error) {
+		atomic.AddInt32(&loads[key.(int)], 1)
+		return key, true, nil
+	})
+
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(keyCount * callerCount)
+
+	/// When
+	for i := 0; i < keyCount*callerCount; i++ {
+		go func(key int) {
+			defer waitGroup.Done()
+
+			if value, _ := rtm.Get(key); value != key {
+				t.Errorf("Should return loaded value for %d, but got %v", key, value)
+			}
+		}(i % keyCount)
+	}
+
+	waitGroup.Wait()
+
+	/// Then
+	for key, count := range loads {
+		if count != 1 {
+			t.Errorf("Should load %d exactly once, but got %d", key, count)
+		}
+	}
+}
+
+func TestReadThroughMapNegativeCacheTTL(t *testing.T) {
+	/// Setup
+	clock := newFakeClock()
+	loads := 0
+
+	rtm := newReadThroughMap(NewDefaultBasicMap(), func(key interface{}) (interface{}, bool, error) {
+		loads++
+		return nil, false, nil
+	}, clock, WithNegativeCacheTTL(time.Minute))
+
+	/// When & Then
+	rtm.Get(1)
+	rtm.Get(1)
+
+	if loads != 1 {
+		t.Errorf("Should skip loader for negatively cached key, but got %d loads", loads)
+	}
+
+	if rtm.Contains(1) || rtm.Length() != 0 {
+		t.Errorf("Should not store negatively cached key as an entry")
+	}
+
+	clock.Advance(time.Minute - time.Second)
+	rtm.Get(1)
+
+	if loads != 1 {
+		t.Errorf("Should skip loader before TTL expires")
+	}
+
+	clock.Advance(time.Second)
+	rtm.Get(1)
+
+	if loads != 2 {
+		t.Errorf("Should call loader again after TTL expires, but got %d loads", loads)
+	}
+
+	rtm.Set(1, "set")
+
+	if value, found := rtm.Get(1); !found || value != "set" || loads != 2 {
+		t.Errorf("Should return value set over negative entry")
+	}
+}
+
+func TestReadThroughMapGetMetered(t *testing.T) {
+	/// Setup
+	clock := newFakeClock()
+	loadLatency := 100 * time.Millisecond
+
+	rtm := newReadThroughMap(NewDefaultBasicMap(), func(key interface{}) (interface{}, bool, error) {
+		clock.Advance(loadLatency)
+		return key, true, nil
+	}, clock)
+
+	/// When
+	_, coldFound, coldLatency := rtm.GetMetered(1)
+	warmValue, warmFound, warmLatency := rtm.GetMetered(1)
+
+	/// Then
+	if coldLatency != loadLatency || !coldFound {
+		t.Errorf("Should include loader call in latency, but got %v", coldLatency)
+	}
+
+	if warmLatency != 0 || !warmFound || warmValue != 1 {
+		t.Errorf("Should report cache hit without loader latency, but got %v", warmLatency)
+	}
+}
+
+func TestReadThroughMapContextLoader(t *testing.T) {
+	/// Setup
+	type traceKey struct{}
+	var traceID interface{}
+
+	rtm := NewReadThroughMap(NewDefaultBasicMap(), nil, WithContextLoader(func(ctx context.Context, key interface{}) (interface{}, bool, error) {
+		traceID = ctx.Value(traceKey{})
+		return key, true, nil
+	}))
+
+	/// When
+	value, found, err := rtm.LoadContext(context.WithValue(context.Background(), traceKey{}, "trace-1"), "key")
+
+	/// Then
+	if value != "key" || !found || err != nil {
+		t.Errorf("Should load value with context loader, but got %v, %t, %v", value, found, err)
+	}
+
+	if traceID != "trace-1" {
+		t.Errorf("Should pass caller context to loader, but got %v", traceID)
+	}
+
+	if _, found := rtm.Get("other"); !found || traceID != nil {
+		t.Errorf("Should pass background context from Get")
+	}
+}
+
+func TestReadThroughMapLoaderPanic(t *testing.T) {
+	/// Setup
+	fail := true
+
+	rtm := NewReadThroughMap(NewDefaultBasicMap(), func(key interface{}) (interface{}, bool, error) {
+		if fail {
+			panic("Loader failure")
+		}
+
+		return 1, true, nil
+	})
+
+	/// When
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "Loader failure" {
+				t.Errorf("Should propagate loader panic, but got %v", recovered)
+			}
+		}()
+
+		rtm.Load("Key")
+	}()
+
+	fail = false
+	doneCh := make(chan interface{})
+
+	go func() {
+		defer close(doneCh)
+
+		if value, found, err := rtm.Load("Key"); value != 1 || !found || err != nil {
+			t.Errorf("Should load again after a panic, but got %v, %t, %v", value, found, err)
+		}
+	}()
+
+	/// Then
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Errorf("Should not block later loads after a panic")
+	}
+}
+
+func TestReadThroughMapLoaderPanicWaiters(t *testing.T) {
+	/// Setup
+	startedCh, releaseCh := make(chan interface{}), make(chan interface{})
+
+	rtm := NewReadThroughMap(NewDefaultBasicMap(), func(key interface{}) (interface{}, bool, error) {
+		close(startedCh)
+		<-releaseCh
+		panic("Loader failure")
+	})
+
+	starterDone := make(chan interface{})
+
+	go func() {
+		defer close(starterDone)
+		defer func() { recover() }()
+		rtm.Load("Key")
+	}()
+
+	<-startedCh
+	waiterDone := make(chan error)
+
+	go func() {
+		_, _, err := rtm.Load("Key")
+		waiterDone <- err
+	}()
+
+	/// When
+	time.Sleep(20 * time.Millisecond)
+	close(releaseCh)
+	<-starterDone
+
+	/// Then
+	if err := <-waiterDone; !errors.Is(err, ErrLoaderPanicked) {
+		t.Errorf("Should report loader panic to waiters, but got %v", err)
+	}
+}
+
+func TestReadThroughMapWriteDuringLoad(t *testing.T) {
+	writes := map[string]func(rtm ReadThroughMap){
+		"Set":    func(rtm ReadThroughMap) { rtm.Set("Key", "Fresh") },
+		"Delete": func(rtm ReadThroughMap) { rtm.Delete("Key") },
+		"Clear":  func(rtm ReadThroughMap) { rtm.Clear() },
+	}
+
+	for name, write := range writes {
+		/// Setup
+		startedCh, releaseCh := make(chan interface{}), make(chan interface{})
+
+		rtm := NewReadThroughMap(NewDefaultBasicMap(), func(key interface{}) (interface{}, bool, error) {
+			close(startedCh)
+			<-releaseCh
+			return "Stale", true, nil
+		})
+
+		loadDone := make(chan interface{})
+
+		go func() {
+			defer close(loadDone)
+			rtm.Load("Key")
+		}()
+
+		<-startedCh
+
+		/// When
+		write(rtm)
+		close(releaseCh)
+		<-loadDone
+
+		/// Then
+		if name == "Set" {
+			if value, _ := rtm.Get("Key"); value != "Fresh" {
+				t.Errorf("Should keep the value set during the load, but got %v", value)
+			}
+		} else if rtm.Contains("Key") {
+			t.Errorf("Should not cache a load that overlapped %s", name)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package gomap
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ReplicatingMap represents a Map that copies its mutations to replica maps.
+// Replication is best-effort and asynchronous: each target applies operations
+// in order on its own goroutine, so slow or failing targets never block the
+// primary, and reads only consult the primary.
+type ReplicatingMap interface {
+	Map
+
+	// ReplicateTo starts copying subsequent Set, Delete and Clear operations to
+	// targets. Existing entries are not copied.
+	ReplicateTo(targets ...Map)
+
+	// Stop stops replication, discarding operations that have not been applied
+	// to targets yet.
+	Stop()
+}
+
+// Each replica has its own goroutine that drains an unbounded queue of pending
+// operations into the target.
+type replica struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	target  Map
+	pending []func(target Map)
+	stopped bool
+	onError func(target Map, err error)
+}
+
+func (r *replica) push(op func(target Map)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.pending = append(r.pending, op)
+	r.cond.Signal()
+}
+
+func (r *replica) stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stopped = true
+	r.pending = nil
+	r.cond.Signal()
+}
+
+// A panicking target is reported to onError, and replication to it continues
+// with the next operation.
+func (r *replica) apply(op func(target Map)) {
+	defer func() {
+		if recovered := recover(); recovered != nil && r.onError != nil {
+			r.onError(r.target, fmt.Errorf("%w: %v", ErrReplicationFailed, recovered))
+		}
+	}()
+
+	op(r.target)
+}
+
+func (r *replica) replicate() {
+	for {
+		r.mutex.Lock()
+
+		for len(r.pending) == 0 && !r.stopped {
+			r.cond.Wait()
+		}
+
+		if r.stopped {
+			r.mutex.Unlock()
+			return
+		}
+
+		op := r.pending[0]
+		r.pending = r.pending[1:]
+		r.mutex.Unlock()
+		r.apply(op)
+	}
+}
+
+func newReplica(target Map, onError func(target Map, err error)) *replica {
+	r := &replica{target: target, onError: onError}
+	r.cond = sync.NewCond(&r.mutex)
+	go r.replicate()
+	return r
+}
+
+// The mutex orders mutations, so that every replica receives them in the same
+// order as the primary.
+type replicatingMap struct {
+	mutex    sync.Mutex
+	primary  Map
+	replicas []*replica
+	onError  func(target Map, err error)
+}
+
+func (rm *replicatingMap) String() string {
+	return fmt.Sprint(rm.primary)
+}
+
+func (rm *replicatingMap) Clear() {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	rm.primary.Clear()
+	rm.push(func(target Map) { target.Clear() })
+}
+
+func (rm *replicatingMap) Contains(key interface{}) bool {
+	return rm.primary.Contains(key)
+}
+
+func (rm *replicatingMap) Delete(key interface{}) (interface{}, bool) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	prev, found := rm.primary.Delete(key)
+	rm.push(func(target Map) { target.Delete(key) })
+	return prev, found
+}
+
+func (rm *replicatingMap) Get(key interface{}) (interface{}, bool) {
+	return rm.primary.Get(key)
+}
+
+func (rm *replicatingMap) Length() int {
+	return rm.primary.Length()
+}
+
+func (rm *replicatingMap) Keys() []interface{} {
+	return rm.primary.Keys()
+}
+
+func (rm *replicatingMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	prev, found := rm.primary.Set(key, value)
+	rm.push(func(target Map) { target.Set(key, value) })
+	return prev, found
+}
+
+func (rm *replicatingMap) ReplicateTo(targets ...Map) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	for _, target := range targets {
+		rm.replicas = append(rm.replicas, newReplica(target, rm.onError))
+	}
+}
+
+func (rm *replicatingMap) Stop() {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	for _, replica := range rm.replicas {
+		replica.stop()
+	}
+
+	rm.replicas = nil
+}
+
+func (rm *replicatingMap) push(op func(target Map)) {
+	for _, replica := range rm.replicas {
+		replica.push(op)
+	}
+}
+
+// NewReplicatingMap returns a new ReplicatingMap that stores its entries in
+// primary. onError, which may be nil, is called on the replica's goroutine with
+// an error wrapping ErrReplicationFailed whenever a target panics.
+func NewReplicatingMap(primary Map, onError func(target Map, err error)) ReplicatingMap {
+	return &replicatingMap{primary: primary, onError: onError}
+}
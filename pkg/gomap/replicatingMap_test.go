@@ -0,0 +1,106 @@
+package gomap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type blockingMap struct {
+	Map
+	unblockCh chan interface{}
+}
+
+func (bm *blockingMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	<-bm.unblockCh
+	return bm.Map.Set(key, value)
+}
+
+type failingSetMap struct {
+	Map
+}
+
+func (fm *failingSetMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	panic("Replica failure")
+}
+
+func waitUntil(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Should have met condition in time")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReplicatingMapReplicateTo(t *testing.T) {
+	/// Setup
+	rm := NewReplicatingMap(NewDefaultBasicMap(), nil)
+	defer rm.Stop()
+	target1 := NewLockConcurrentMap(NewDefaultBasicMap())
+	target2 := NewLockConcurrentMap(NewDefaultBasicMap())
+	rm.Set("before", 0)
+	rm.ReplicateTo(target1, target2)
+
+	/// When
+	for i := 0; i < 100; i++ {
+		rm.Set(i, i)
+	}
+
+	rm.Delete(0)
+
+	/// Then
+	for _, target := range []Map{target1, target2} {
+		waitUntil(t, func() bool { return target.Length() == 99 })
+
+		if target.Contains("before") || target.Contains(0) {
+			t.Errorf("Should only replicate subsequent mutations, in order")
+		}
+	}
+
+	rm.Clear()
+	waitUntil(t, func() bool { return target1.Length() == 0 && target2.Length() == 0 })
+}
+
+func TestReplicatingMapFailingTarget(t *testing.T) {
+	/// Setup
+	errCh := make(chan error, 1)
+
+	rm := NewReplicatingMap(NewDefaultBasicMap(), func(target Map, err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
+	})
+
+	defer rm.Stop()
+	blocking := &blockingMap{Map: NewDefaultBasicMap(), unblockCh: make(chan interface{})}
+	defer close(blocking.unblockCh)
+	healthy := NewLockConcurrentMap(NewDefaultBasicMap())
+	rm.ReplicateTo(&failingSetMap{NewDefaultBasicMap()}, blocking, healthy)
+
+	/// When
+	for i := 0; i < 100; i++ {
+		rm.Set(i, i)
+	}
+
+	/// Then
+	if rm.Length() != 100 {
+		t.Errorf("Should not stall primary on failing targets")
+	}
+
+	waitUntil(t, func() bool { return healthy.Length() == 100 })
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, ErrReplicationFailed) {
+			t.Errorf("Should report ErrReplicationFailed, but got %v", err)
+		}
+
+	case <-time.After(time.Second):
+		t.Errorf("Should report panicking target")
+	}
+}
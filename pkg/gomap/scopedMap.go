@@ -0,0 +1,76 @@
+package gomap
+
+import (
+	"fmt"
+	"strings"
+)
+
+type scopedMap struct {
+	ops    *concurrentOps
+	prefix string
+}
+
+func (sm *scopedMap) scopedKey(key interface{}) string {
+	return sm.prefix + fmt.Sprint(key)
+}
+
+func (sm *scopedMap) isScoped(key interface{}) bool {
+	str, ok := key.(string)
+	return ok && strings.HasPrefix(str, sm.prefix)
+}
+
+func (sm *scopedMap) String() string {
+	entries := make([]Entry, 0)
+
+	sm.ops.accessor.readStorage(func(storage Map) {
+		for _, entry := range entriesOf(storage) {
+			if sm.isScoped(entry.Key) {
+				entry.Key = strings.TrimPrefix(entry.Key.(string), sm.prefix)
+				entries = append(entries, entry)
+			}
+		}
+	})
+
+	return formatEntries(entries)
+}
+
+// This only removes the keys within the scope.
+func (sm *scopedMap) Clear() {
+	sm.ops.accessor.writeStorage(func(storage Map) {
+		for _, key := range storage.Keys() {
+			if sm.isScoped(key) {
+				storage.Delete(key)
+			}
+		}
+	})
+}
+
+func (sm *scopedMap) Contains(key interface{}) bool {
+	return sm.ops.accessor.Contains(sm.scopedKey(key))
+}
+
+func (sm *scopedMap) Delete(key interface{}) (interface{}, bool) {
+	return sm.ops.accessor.Delete(sm.scopedKey(key))
+}
+
+func (sm *scopedMap) Get(key interface{}) (interface{}, bool) {
+	return sm.ops.accessor.Get(sm.scopedKey(key))
+}
+
+func (sm *scopedMap) Length() int {
+	return len(sm.ops.KeysWithPrefix(sm.prefix))
+}
+
+func (sm *scopedMap) Keys() []interface{} {
+	keys := sm.ops.KeysWithPrefix(sm.prefix)
+
+	for ix := range keys {
+		keys[ix] = strings.TrimPrefix(keys[ix].(string), sm.prefix)
+	}
+
+	return keys
+}
+
+func (sm *scopedMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	return sm.ops.accessor.Set(sm.scopedKey(key), value)
+}
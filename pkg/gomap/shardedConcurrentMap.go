@@ -0,0 +1,317 @@
+package gomap
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+const defaultShardCount = 16
+
+// ShardedConcurrentMap represents a ConcurrentMap that splits its keys across
+// several independently locked shards.
+type ShardedConcurrentMap interface {
+	ConcurrentMap
+
+	// KeyDistribution returns the number of entries in each shard, in shard
+	// index order, to help diagnose a hash function that makes some shards hot.
+	KeyDistribution() []int
+}
+
+type mapShard struct {
+	mutex   sync.RWMutex
+	storage Map
+}
+
+// This accesses the storages of all shards without locking them, and must only
+// be used while the relevant locks are held.
+type shardView struct {
+	shards  []*mapShard
+	hashFn  func(key interface{}) uint64
+	keyLess func(a, b interface{}) bool
+	ring    *hashRing
+}
+
+type shardedConcurrentMap struct {
+	*concurrentOps
+	view *shardView
+}
+
+// ShardedConcurrentMapParams represents all the required parameters to build a
+// sharded ConcurrentMap.
+type ShardedConcurrentMapParams struct {
+	ShardCount uint
+
+	// HashFn hashes keys to pick their shards. Equal keys must have equal
+	// hashes. Defaults to an FNV hash of the kind and value of the key, with
+	// pointers hashed by address.
+	HashFn func(key interface{}) uint64
+
+	// KeyLess orders the keys within each shard. If set, Keys and every other
+	// operation that iterates the map visits shards in index order and keys in
+	// KeyLess order within each shard, so that an unchanged map always yields
+	// the same order.
+	KeyLess func(a, b interface{}) bool
+
+	// StorageFn creates the storage for each shard. Defaults to BasicMap.
+	StorageFn func() Map
+
+	// VirtualNodes routes keys with a consistent hash ring holding VirtualNodes
+	// points per shard, instead of taking the key hash modulo ShardCount. A map
+	// with one more shard then places only about 1/ShardCount of the keys
+	// differently. More points spread keys more evenly. Defaults to 0, which
+	// keeps modulo routing.
+	VirtualNodes uint
+}
+
+func (v *shardView) shardFor(key interface{}) *mapShard {
+	return v.shards[v.shardIndex(key)]
+}
+
+func (v *shardView) shardIndex(key interface{}) int {
+	if v.ring != nil {
+		return v.ring.shardFor(v.hashFn(key))
+	}
+
+	return int(v.hashFn(key) % uint64(len(v.shards)))
+}
+
+func (v *shardView) String() string {
+	return formatEntries(entriesOf(v))
+}
+
+func (v *shardView) Clear() {
+	for _, shard := range v.shards {
+		shard.storage.Clear()
+	}
+}
+
+func (v *shardView) Contains(key interface{}) bool {
+	return v.shardFor(key).storage.Contains(key)
+}
+
+func (v *shardView) Delete(key interface{}) (interface{}, bool) {
+	return v.shardFor(key).storage.Delete(key)
+}
+
+func (v *shardView) Get(key interface{}) (interface{}, bool) {
+	return v.shardFor(key).storage.Get(key)
+}
+
+func (v *shardView) Length() int {
+	length := 0
+
+	for _, shard := range v.shards {
+		length += shard.storage.Length()
+	}
+
+	return length
+}
+
+func (v *shardView) Keys() []interface{} {
+	keys := make([]interface{}, 0)
+
+	for _, shard := range v.shards {
+		keys = append(keys, v.keysOf(shard)...)
+	}
+
+	return keys
+}
+
+// List the keys of shard, in keyLess order if it is set.
+func (v *shardView) keysOf(shard *mapShard) []interface{} {
+	keys := shard.storage.Keys()
+
+	if v.keyLess != nil {
+		sort.Slice(keys, func(i, j int) bool {
+			return v.keyLess(keys[i], keys[j])
+		})
+	}
+
+	return keys
+}
+
+func (v *shardView) Set(key interface{}, value interface{}) (interface{}, bool) {
+	return v.shardFor(key).storage.Set(key, value)
+}
+
+// Shards are always locked in index order to avoid deadlocks.
+func (scm *shardedConcurrentMap) readStorage(fn func(storage Map)) {
+	for _, shard := range scm.view.shards {
+		shard.mutex.RLock()
+		defer shard.mutex.RUnlock()
+	}
+
+	fn(scm.view)
+}
+
+func (scm *shardedConcurrentMap) writeStorage(fn func(storage Map)) {
+	for _, shard := range scm.view.shards {
+		shard.mutex.Lock()
+		defer shard.mutex.Unlock()
+	}
+
+	fn(scm.view)
+}
+
+func (scm *shardedConcurrentMap) writeKeyStorage(key interface{}, fn func(storage Map)) {
+	shard := scm.view.shardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	fn(shard.storage)
+}
+
+func (scm *shardedConcurrentMap) String() string {
+	var str string
+
+	scm.readStorage(func(storage Map) {
+		str = fmt.Sprint(storage)
+	})
+
+	return str
+}
+
+func (scm *shardedConcurrentMap) Clear() {
+	scm.writeStorage(func(storage Map) {
+		storage.Clear()
+	})
+}
+
+func (scm *shardedConcurrentMap) Contains(key interface{}) bool {
+	shard := scm.view.shardFor(key)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	return shard.storage.Contains(key)
+}
+
+func (scm *shardedConcurrentMap) Delete(key interface{}) (interface{}, bool) {
+	shard := scm.view.shardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	return shard.storage.Delete(key)
+}
+
+func (scm *shardedConcurrentMap) Get(key interface{}) (interface{}, bool) {
+	shard := scm.view.shardFor(key)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	return shard.storage.Get(key)
+}
+
+func (scm *shardedConcurrentMap) Length() int {
+	var length int
+
+	scm.readStorage(func(storage Map) {
+		length = storage.Length()
+	})
+
+	return length
+}
+
+func (scm *shardedConcurrentMap) Keys() []interface{} {
+	var keys []interface{}
+
+	scm.readStorage(func(storage Map) {
+		keys = storage.Keys()
+	})
+
+	return keys
+}
+
+func (scm *shardedConcurrentMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	shard := scm.view.shardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	return shard.storage.Set(key, value)
+}
+
+func (scm *shardedConcurrentMap) shardCount() int {
+	return len(scm.view.shards)
+}
+
+func (scm *shardedConcurrentMap) shardKeys(index int) []interface{} {
+	shard := scm.view.shards[index]
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	return scm.view.keysOf(shard)
+}
+
+func (scm *shardedConcurrentMap) KeyDistribution() []int {
+	distribution := make([]int, len(scm.view.shards))
+
+	for ix, shard := range scm.view.shards {
+		shard.mutex.RLock()
+		distribution[ix] = shard.storage.Length()
+		shard.mutex.RUnlock()
+	}
+
+	return distribution
+}
+
+// CoefficientOfVariation returns the standard deviation of counts divided by
+// their mean, e.g. for the result of KeyDistribution. 0 means perfectly even
+// counts, and larger values mean more skew. This returns 0 if counts is empty
+// or sums to 0.
+func CoefficientOfVariation(counts []int) float64 {
+	total := 0
+
+	for _, count := range counts {
+		total += count
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	mean := float64(total) / float64(len(counts))
+	variance := 0.0
+
+	for _, count := range counts {
+		variance += (float64(count) - mean) * (float64(count) - mean)
+	}
+
+	return math.Sqrt(variance/float64(len(counts))) / mean
+}
+
+// NewShardedConcurrentMap returns a new ShardedConcurrentMap that splits its
+// keys across several independently locked shards, reducing lock contention
+// when many goroutines access the map at once.
+func NewShardedConcurrentMap(params ShardedConcurrentMapParams) ShardedConcurrentMap {
+	shardCount := params.ShardCount
+	hashFn := params.HashFn
+	storageFn := params.StorageFn
+
+	if shardCount == 0 {
+		shardCount = defaultShardCount
+	}
+
+	if hashFn == nil {
+		hashFn = hashKey
+	}
+
+	if storageFn == nil {
+		storageFn = NewDefaultBasicMap
+	}
+
+	shards := make([]*mapShard, shardCount)
+
+	for ix := range shards {
+		shards[ix] = &mapShard{storage: storageFn()}
+	}
+
+	view := &shardView{shards: shards, hashFn: hashFn, keyLess: params.KeyLess}
+
+	if params.VirtualNodes > 0 {
+		view.ring = newHashRing(int(shardCount), int(params.VirtualNodes))
+	}
+
+	scm := &shardedConcurrentMap{view: view}
+	scm.concurrentOps = &concurrentOps{accessor: scm}
+	return scm
+}
+
+// NewDefaultShardedConcurrentMap returns a new default ShardedConcurrentMap.
+func NewDefaultShardedConcurrentMap() ShardedConcurrentMap {
+	return NewShardedConcurrentMap(ShardedConcurrentMapParams{})
+}
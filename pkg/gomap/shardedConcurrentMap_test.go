@@ -0,0 +1,168 @@
+package gomap
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestShardedConcurrentMapDeterministicKeys(t *testing.T) {
+	/// Setup
+	cm := NewShardedConcurrentMap(ShardedConcurrentMapParams{
+		ShardCount: 4,
+		KeyLess:    func(a, b interface{}) bool { return a.(int) < b.(int) },
+	})
+
+	for i := 0; i < 1000; i++ {
+		cm.Set(i, i)
+	}
+
+	/// When
+	first := cm.Keys()
+	second := cm.Keys()
+
+	/// Then
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Should return keys in the same order on an unchanged map")
+	}
+
+	for ix := 1; ix < len(first); ix++ {
+		previous, key := first[ix-1], first[ix]
+
+		if hashKey(previous)%4 == hashKey(key)%4 && previous.(int) > key.(int) {
+			t.Errorf("Should sort keys within each shard, but got %v before %v", previous, key)
+		}
+	}
+}
+
+func TestShardedConcurrentMapConsistentHashing(t *testing.T) {
+	/// Setup
+	newView := func(shardCount uint, virtualNodes uint) *shardView {
+		params := ShardedConcurrentMapParams{ShardCount: shardCount, VirtualNodes: virtualNodes}
+		return NewShardedConcurrentMap(params).(*shardedConcurrentMap).view
+	}
+
+	keyCount := 10000
+	ringBefore, ringAfter := newView(8, 100), newView(9, 100)
+	moduloBefore, moduloAfter := newView(8, 0), newView(9, 0)
+	ringMoved, moduloMoved := 0, 0
+
+	/// When
+	for key := 0; key < keyCount; key++ {
+		if ringBefore.shardIndex(key) != ringAfter.shardIndex(key) {
+			ringMoved++
+		}
+
+		if moduloBefore.shardIndex(key) != moduloAfter.shardIndex(key) {
+			moduloMoved++
+		}
+	}
+
+	/// Then
+	if ringMoved == 0 || ringMoved > keyCount*2/9 {
+		t.Errorf("Should remap about 1/9 of keys with a ring, but got %d/%d", ringMoved, keyCount)
+	}
+
+	if moduloMoved < keyCount/2 {
+		t.Errorf("Should remap most keys with modulo routing, but got %d/%d", moduloMoved, keyCount)
+	}
+
+	for key := 0; key < keyCount; key++ {
+		if shard := ringAfter.shardIndex(key); ringBefore.shardIndex(key) != shard && shard != 8 {
+			t.Fatalf("Should only move keys to the new shard, but moved %d to %d", key, shard)
+		}
+	}
+}
+
+func TestShardedConcurrentMapKeyDistribution(t *testing.T) {
+	/// Setup
+	skewedHash := func(key interface{}) uint64 {
+		if key.(int)%10 == 0 {
+			return 1
+		}
+
+		return 0
+	}
+
+	skewed := NewShardedConcurrentMap(ShardedConcurrentMapParams{ShardCount: 4, HashFn: skewedHash})
+	even := NewShardedConcurrentMap(ShardedConcurrentMapParams{ShardCount: 4})
+
+	for i := 0; i < 1000; i++ {
+		skewed.Set(i, i)
+		even.Set(i, i)
+	}
+
+	/// When
+	distribution := skewed.KeyDistribution()
+
+	/// Then
+	if !reflect.DeepEqual(distribution, []int{900, 100, 0, 0}) {
+		t.Errorf("Should reflect the skewed hash, but got %v", distribution)
+	}
+
+	if skewedCV, evenCV := CoefficientOfVariation(distribution), CoefficientOfVariation(even.KeyDistribution()); skewedCV < 1 || evenCV > 0.2 {
+		t.Errorf("Should report high variation only for skew, but got %f and %f", skewedCV, evenCV)
+	}
+
+	if cv := CoefficientOfVariation(nil); cv != 0 {
+		t.Errorf("Should return 0 for no counts, but got %f", cv)
+	}
+}
+
+func TestShardedConcurrentMapVerify(t *testing.T) {
+	/// Setup
+	cm := NewShardedConcurrentMap(ShardedConcurrentMapParams{ShardCount: 4})
+	cm.Set("key", 1)
+	view := cm.(*shardedConcurrentMap).view
+	owner := view.shardIndex("key")
+
+	/// When
+	view.shards[owner].storage.Delete("key")
+	view.shards[(owner+1)%4].storage.Set("key", 1)
+
+	/// Then
+	if err := cm.Verify(); !errors.Is(err, ErrInvariantViolated) {
+		t.Errorf("Should detect a key in the wrong shard, but got %v", err)
+	}
+}
+
+type renamableKey struct {
+	name string
+}
+
+func (key *renamableKey) String() string {
+	return key.name
+}
+
+func TestShardedConcurrentMapMutableStringKey(t *testing.T) {
+	/// Setup
+	cm := NewShardedConcurrentMap(ShardedConcurrentMapParams{ShardCount: 64})
+	keys := make([]*renamableKey, 100)
+
+	for ix := range keys {
+		keys[ix] = &renamableKey{name: fmt.Sprint(ix)}
+		cm.Set(keys[ix], ix)
+	}
+
+	/// When
+	for ix, key := range keys {
+		key.name = fmt.Sprint(ix + len(keys))
+	}
+
+	/// Then
+	for ix, key := range keys {
+		if value, found := cm.Get(key); !found || value != ix {
+			t.Errorf("Should find pointer key after its String output changes, but got %v", value)
+		}
+	}
+
+	if hashKey(struct{ a, b int }{1, 2}) != hashKey(struct{ a, b int }{1, 2}) || hashKey(0.0) != hashKey(math.Copysign(0, -1)) {
+		t.Errorf("Should hash equal keys the same")
+	}
+
+	if hashKey(1) == hashKey(int64(1)) || hashKey(1) == hashKey("1") {
+		t.Errorf("Should tell keys of different kinds apart")
+	}
+}
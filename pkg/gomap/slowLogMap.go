@@ -0,0 +1,72 @@
+package gomap
+
+import (
+	"fmt"
+	"time"
+)
+
+// Every operation is timed with two clock reads, and log is only called for
+// operations that take longer than threshold.
+type slowLogMap struct {
+	storage   Map
+	threshold time.Duration
+	log       func(op string, key interface{}, d time.Duration)
+	clock     clock
+}
+
+// Call log if the operation that started at start was slow.
+func (slm *slowLogMap) observe(op string, key interface{}, start time.Time) {
+	if d := slm.clock.Now().Sub(start); d > slm.threshold {
+		slm.log(op, key, d)
+	}
+}
+
+func (slm *slowLogMap) String() string {
+	return fmt.Sprint(slm.storage)
+}
+
+func (slm *slowLogMap) Clear() {
+	defer slm.observe("Clear", nil, slm.clock.Now())
+	slm.storage.Clear()
+}
+
+func (slm *slowLogMap) Contains(key interface{}) bool {
+	defer slm.observe("Contains", key, slm.clock.Now())
+	return slm.storage.Contains(key)
+}
+
+func (slm *slowLogMap) Delete(key interface{}) (interface{}, bool) {
+	defer slm.observe("Delete", key, slm.clock.Now())
+	return slm.storage.Delete(key)
+}
+
+func (slm *slowLogMap) Get(key interface{}) (interface{}, bool) {
+	defer slm.observe("Get", key, slm.clock.Now())
+	return slm.storage.Get(key)
+}
+
+func (slm *slowLogMap) Length() int {
+	defer slm.observe("Length", nil, slm.clock.Now())
+	return slm.storage.Length()
+}
+
+func (slm *slowLogMap) Keys() []interface{} {
+	defer slm.observe("Keys", nil, slm.clock.Now())
+	return slm.storage.Keys()
+}
+
+func (slm *slowLogMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	defer slm.observe("Set", key, slm.clock.Now())
+	return slm.storage.Set(key, value)
+}
+
+func newSlowLogMap(storage Map, threshold time.Duration, log func(op string, key interface{}, d time.Duration), clock clock) *slowLogMap {
+	return &slowLogMap{storage: storage, threshold: threshold, log: log, clock: clock}
+}
+
+// NewSlowLogMap returns a Map that forwards every operation to storage, and
+// calls log with the name of the operation, its key if any, and its duration
+// whenever it takes longer than threshold. log runs on the calling goroutine.
+func NewSlowLogMap(storage Map, threshold time.Duration, log func(op string, key interface{}, d time.Duration)) Map {
+	return newSlowLogMap(storage, threshold, log, systemClock{})
+}
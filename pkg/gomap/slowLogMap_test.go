@@ -0,0 +1,48 @@
+package gomap
+
+import (
+	"testing"
+	"time"
+)
+
+// A storage whose Get takes delay on a fake clock.
+type delayedGetMap struct {
+	Map
+	clock *fakeClock
+	delay time.Duration
+}
+
+func (dgm *delayedGetMap) Get(key interface{}) (interface{}, bool) {
+	dgm.clock.Advance(dgm.delay)
+	return dgm.Map.Get(key)
+}
+
+func TestSlowLogMapLogsSlowOperations(t *testing.T) {
+	/// Setup
+	threshold := 10 * time.Millisecond
+	fakeClock := newFakeClock()
+	storage := &delayedGetMap{Map: NewDefaultBasicMap(), clock: fakeClock, delay: 2 * threshold}
+	var ops []string
+	var keys []interface{}
+	var durations []time.Duration
+
+	slm := newSlowLogMap(storage, threshold, func(op string, key interface{}, d time.Duration) {
+		ops = append(ops, op)
+		keys = append(keys, key)
+		durations = append(durations, d)
+	}, fakeClock)
+
+	/// When
+	slm.Set("key", 1)
+	value, _ := slm.Get("key")
+	slm.Delete("key")
+
+	/// Then
+	if value != 1 {
+		t.Errorf("Should forward operations, but got %v", value)
+	}
+
+	if len(ops) != 1 || ops[0] != "Get" || keys[0] != "key" || durations[0] != 2*threshold {
+		t.Errorf("Should only log the slow Get, but got %v %v %v", ops, keys, durations)
+	}
+}
@@ -0,0 +1,73 @@
+package gomap
+
+import (
+	"sync"
+)
+
+// Every operation is forwarded to the underlying sync.Map, so the adapter is as
+// safe for concurrent use as the sync.Map itself. Operations that visit every
+// entry are not atomic, since sync.Map offers no consistent snapshot.
+type syncMapAdapter struct {
+	storage *sync.Map
+}
+
+func (sma *syncMapAdapter) String() string {
+	return formatEntries(entriesOf(sma))
+}
+
+// This deletes the entries one by one instead of calling sync.Map.Clear, which
+// needs Go 1.23.
+func (sma *syncMapAdapter) Clear() {
+	sma.storage.Range(func(key, value interface{}) bool {
+		sma.storage.Delete(key)
+		return true
+	})
+}
+
+func (sma *syncMapAdapter) Contains(key interface{}) bool {
+	_, found := sma.storage.Load(key)
+	return found
+}
+
+func (sma *syncMapAdapter) Delete(key interface{}) (interface{}, bool) {
+	return sma.storage.LoadAndDelete(key)
+}
+
+func (sma *syncMapAdapter) Get(key interface{}) (interface{}, bool) {
+	return sma.storage.Load(key)
+}
+
+// This counts the entries with Range, since sync.Map does not track its length.
+func (sma *syncMapAdapter) Length() int {
+	length := 0
+
+	sma.storage.Range(func(key, value interface{}) bool {
+		length++
+		return true
+	})
+
+	return length
+}
+
+func (sma *syncMapAdapter) Keys() []interface{} {
+	keys := make([]interface{}, 0)
+
+	sma.storage.Range(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	return keys
+}
+
+func (sma *syncMapAdapter) Set(key interface{}, value interface{}) (interface{}, bool) {
+	return sma.storage.Swap(key, value)
+}
+
+// NewFromSyncMap returns a Map that reads and writes m, so that code using a
+// sync.Map can adopt this package incrementally. Length is O(n), since it has
+// to count the entries with Range. This needs Go 1.20 or later, for
+// sync.Map.Swap.
+func NewFromSyncMap(m *sync.Map) Map {
+	return &syncMapAdapter{storage: m}
+}
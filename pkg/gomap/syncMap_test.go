@@ -0,0 +1,50 @@
+package gomap
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSyncMapAdapterForwardsOperations(t *testing.T) {
+	/// Setup
+	syncMap := &sync.Map{}
+	syncMap.Store("existing", 0)
+	m := NewFromSyncMap(syncMap)
+
+	/// When
+	prev, found := m.Set("existing", 1)
+	m.Set("new", 2)
+	deleted, deletedFound := m.Delete("new")
+
+	/// Then
+	if prev != 0 || !found {
+		t.Errorf("Should return previous value from Set, but got %v, %t", prev, found)
+	}
+
+	if value, _ := syncMap.Load("existing"); value != 1 {
+		t.Errorf("Should write to the underlying sync.Map, but got %v", value)
+	}
+
+	if deleted != 2 || !deletedFound {
+		t.Errorf("Should return deleted value, but got %v, %t", deleted, deletedFound)
+	}
+
+	if _, found := syncMap.Load("new"); found {
+		t.Errorf("Should delete from the underlying sync.Map")
+	}
+
+	syncMap.Store("external", 3)
+
+	if value, found := m.Get("external"); value != 3 || !found {
+		t.Errorf("Should read writes made to the sync.Map directly")
+	}
+
+	if m.Length() != 2 || len(m.Keys()) != 2 {
+		t.Errorf("Should count 2 entries, but got %d", m.Length())
+	}
+
+	if str := fmt.Sprint(m); str != "map[existing:1 external:3]" {
+		t.Errorf("Should format entries, but got %s", str)
+	}
+}
@@ -0,0 +1,89 @@
+package gomap
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypedValueMap represents a Map that only accepts values assignable to an
+// expected type, for runtime type safety without generics. Set panics with the
+// error that TrySet would return.
+type TypedValueMap interface {
+	Map
+
+	// TrySet sets key to value if value is assignable to the expected type, or
+	// returns an error wrapping ErrTypeMismatch and leaves the map untouched.
+	TrySet(key interface{}, value interface{}) (interface{}, bool, error)
+}
+
+type typedValueMap struct {
+	storage  Map
+	expected reflect.Type
+}
+
+// A nil value is accepted only if the expected type can hold nil.
+func (tvm *typedValueMap) checkValue(value interface{}) error {
+	if value == nil {
+		switch tvm.expected.Kind() {
+		case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+			return nil
+		}
+	} else if reflect.TypeOf(value).AssignableTo(tvm.expected) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %T is not assignable to %v", ErrTypeMismatch, value, tvm.expected)
+}
+
+func (tvm *typedValueMap) String() string {
+	return fmt.Sprint(tvm.storage)
+}
+
+func (tvm *typedValueMap) Clear() {
+	tvm.storage.Clear()
+}
+
+func (tvm *typedValueMap) Contains(key interface{}) bool {
+	return tvm.storage.Contains(key)
+}
+
+func (tvm *typedValueMap) Delete(key interface{}) (interface{}, bool) {
+	return tvm.storage.Delete(key)
+}
+
+func (tvm *typedValueMap) Get(key interface{}) (interface{}, bool) {
+	return tvm.storage.Get(key)
+}
+
+func (tvm *typedValueMap) Length() int {
+	return tvm.storage.Length()
+}
+
+func (tvm *typedValueMap) Keys() []interface{} {
+	return tvm.storage.Keys()
+}
+
+func (tvm *typedValueMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	prev, found, err := tvm.TrySet(key, value)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return prev, found
+}
+
+func (tvm *typedValueMap) TrySet(key interface{}, value interface{}) (interface{}, bool, error) {
+	if err := tvm.checkValue(value); err != nil {
+		return nil, false, err
+	}
+
+	prev, found := tvm.storage.Set(key, value)
+	return prev, found, nil
+}
+
+// NewTypedValueMap returns a new TypedValueMap backed by BasicMap storage that
+// only accepts values assignable to expected.
+func NewTypedValueMap(expected reflect.Type) TypedValueMap {
+	return &typedValueMap{storage: NewDefaultBasicMap(), expected: expected}
+}
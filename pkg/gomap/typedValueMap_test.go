@@ -0,0 +1,62 @@
+package gomap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTypedValueMapRejectsWrongType(t *testing.T) {
+	/// Setup
+	tvm := NewTypedValueMap(reflect.TypeOf(""))
+	tvm.Set("key", "value")
+
+	/// When
+	prev, found, err := tvm.TrySet("key", 1)
+
+	/// Then
+	if !errors.Is(err, ErrTypeMismatch) || !strings.Contains(err.Error(), "int is not assignable to string") {
+		t.Errorf("Should reject wrong type with a descriptive error, but got %v", err)
+	}
+
+	if prev != nil || found {
+		t.Errorf("Should not return a previous value on rejection")
+	}
+
+	if value, _ := tvm.Get("key"); value != "value" || tvm.Length() != 1 {
+		t.Errorf("Should leave the map unchanged, but got %v", value)
+	}
+
+	if _, _, err := tvm.TrySet("nil", nil); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Should reject nil for a type that cannot hold it")
+	}
+}
+
+func TestTypedValueMapAcceptsAssignableType(t *testing.T) {
+	/// Setup
+	tvm := NewTypedValueMap(reflect.TypeOf((*fmt.Stringer)(nil)).Elem())
+	value := NewDefaultBasicMap().(fmt.Stringer)
+
+	/// When
+	_, _, err := tvm.TrySet("stringer", value)
+	_, _, nilErr := tvm.TrySet("nil", nil)
+
+	/// Then
+	if err != nil || nilErr != nil {
+		t.Errorf("Should accept assignable values, but got %v, %v", err, nilErr)
+	}
+
+	if stored, _ := tvm.Get("stringer"); stored != value {
+		t.Errorf("Should store accepted value")
+	}
+
+	defer func() {
+		if recovered := recover(); recovered == nil {
+			t.Errorf("Set should panic on a wrong type")
+		}
+	}()
+
+	tvm.Set("int", 1)
+}
@@ -0,0 +1,92 @@
+package gomap
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WriteOnceMap represents a Map whose keys cannot be overwritten once Set.
+// Deleting a key allows it to be Set again.
+type WriteOnceMap interface {
+	Map
+
+	// TrySet sets key to value if key is absent, or returns the existing value
+	// together with an error wrapping ErrKeyExists.
+	TrySet(key interface{}, value interface{}) (interface{}, bool, error)
+}
+
+// The mutex serializes every write, so that a Delete or Clear cannot interleave
+// with the check and set of TrySet.
+type writeOnceMap struct {
+	mutex   sync.Mutex
+	storage Map
+}
+
+func (wom *writeOnceMap) String() string {
+	return fmt.Sprint(wom.storage)
+}
+
+func (wom *writeOnceMap) Clear() {
+	wom.mutex.Lock()
+	defer wom.mutex.Unlock()
+	wom.storage.Clear()
+}
+
+func (wom *writeOnceMap) Contains(key interface{}) bool {
+	return wom.storage.Contains(key)
+}
+
+func (wom *writeOnceMap) Delete(key interface{}) (interface{}, bool) {
+	wom.mutex.Lock()
+	defer wom.mutex.Unlock()
+	return wom.storage.Delete(key)
+}
+
+func (wom *writeOnceMap) Get(key interface{}) (interface{}, bool) {
+	return wom.storage.Get(key)
+}
+
+func (wom *writeOnceMap) Length() int {
+	return wom.storage.Length()
+}
+
+func (wom *writeOnceMap) Keys() []interface{} {
+	return wom.storage.Keys()
+}
+
+// If key is already present, this returns the existing value and true without
+// overwriting it.
+func (wom *writeOnceMap) Set(key interface{}, value interface{}) (interface{}, bool) {
+	existing, found, _ := wom.TrySet(key, value)
+	return existing, found
+}
+
+// The mutex is always held, so that writes through this map never race even if
+// the SetIfAbsent of a ConcurrentMap storage is not atomic. The check is still
+// delegated to SetIfAbsent for a ConcurrentMap storage, so that it stays atomic
+// with respect to other users of the storage.
+func (wom *writeOnceMap) TrySet(key interface{}, value interface{}) (interface{}, bool, error) {
+	var existing interface{}
+	var found bool
+
+	wom.mutex.Lock()
+
+	if cm, ok := wom.storage.(ConcurrentMap); ok {
+		existing, found = cm.SetIfAbsent(key, value)
+	} else if existing, found = wom.storage.Get(key); !found {
+		wom.storage.Set(key, value)
+	}
+
+	wom.mutex.Unlock()
+
+	if found {
+		return existing, found, fmt.Errorf("%w: %v", ErrKeyExists, key)
+	}
+
+	return nil, false, nil
+}
+
+// NewWriteOnceMap returns a new WriteOnceMap that stores its entries in storage.
+func NewWriteOnceMap(storage Map) WriteOnceMap {
+	return &writeOnceMap{storage: storage}
+}
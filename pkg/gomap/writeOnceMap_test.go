@@ -0,0 +1,129 @@
+package gomap
+
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func testWriteOnceMapRejectsOverwrite(t *testing.T, wom WriteOnceMap) {
+	/// Setup
+	key := "Key"
+
+	/// When & Then
+	if _, found, err := wom.TrySet(key, 1); found || err != nil {
+		t.Errorf("First write should succeed, but got %v", err)
+	}
+
+	if existing, found, err := wom.TrySet(key, 2); !found || existing != 1 || !errors.Is(err, ErrKeyExists) {
+		t.Errorf("Second write should be rejected, but got %v", err)
+	}
+
+	if existing, found := wom.Set(key, 3); !found || existing != 1 {
+		t.Errorf("Set should not overwrite")
+	}
+
+	if value, _ := wom.Get(key); value != 1 {
+		t.Errorf("First write should win, but got %v", value)
+	}
+
+	wom.Delete(key)
+
+	if _, found, err := wom.TrySet(key, 4); found || err != nil {
+		t.Errorf("Write after delete should succeed, but got %v", err)
+	}
+}
+
+func testWriteOnceMapConcurrentWrites(t *testing.T, wom WriteOnceMap) {
+	/// Setup
+	writerCount := 100
+	var successes int32
+	waitGroup := sync.WaitGroup{}
+
+	/// When
+	for i := 0; i < writerCount; i++ {
+		waitGroup.Add(1)
+
+		go func(i int) {
+			defer waitGroup.Done()
+
+			if _, _, err := wom.TrySet("Key", strconv.Itoa(i)); err == nil {
+				atomic.AddInt32(&successes, 1)
+			}
+		}(i)
+	}
+
+	waitGroup.Wait()
+
+	/// Then
+	if successes != 1 {
+		t.Errorf("Exactly one write should succeed, but got %d", successes)
+	}
+}
+
+func TestWriteOnceMapBasicStorage(t *testing.T) {
+	testWriteOnceMapRejectsOverwrite(t, NewWriteOnceMap(NewDefaultBasicMap()))
+	testWriteOnceMapConcurrentWrites(t, NewWriteOnceMap(NewDefaultBasicMap()))
+}
+
+func TestWriteOnceMapConcurrentStorage(t *testing.T) {
+	testWriteOnceMapRejectsOverwrite(t, NewWriteOnceMap(NewDefaultShardedConcurrentMap()))
+	testWriteOnceMapConcurrentWrites(t, NewWriteOnceMap(NewDefaultShardedConcurrentMap()))
+}
+
+// This checks and sets in separate steps, yielding in between to widen the race.
+type racySetIfAbsentMap struct {
+	ConcurrentMap
+}
+
+func (rm *racySetIfAbsentMap) SetIfAbsent(key interface{}, value interface{}) (interface{}, bool) {
+	if existing, found := rm.Get(key); found {
+		return existing, found
+	}
+
+	runtime.Gosched()
+	rm.Set(key, value)
+	return nil, false
+}
+
+func TestWriteOnceMapNonAtomicConcurrentStorage(t *testing.T) {
+	storage := &racySetIfAbsentMap{ConcurrentMap: NewLockConcurrentMap(NewDefaultBasicMap())}
+	testWriteOnceMapConcurrentWrites(t, NewWriteOnceMap(storage))
+}
+
+func TestWriteOnceMapConcurrentDeleteAndClear(t *testing.T) {
+	/// Setup
+	wom := NewWriteOnceMap(NewDefaultBasicMap())
+	writerCount := 50
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(writerCount * 3)
+
+	/// When
+	for i := 0; i < writerCount; i++ {
+		go func(i int) {
+			defer waitGroup.Done()
+			wom.TrySet(i, i)
+		}(i)
+
+		go func(i int) {
+			defer waitGroup.Done()
+			wom.Delete(i)
+		}(i)
+
+		go func() {
+			defer waitGroup.Done()
+			wom.Clear()
+		}()
+	}
+
+	waitGroup.Wait()
+	wom.Clear()
+
+	/// Then
+	if _, found, err := wom.TrySet("Key", 1); found || err != nil {
+		t.Errorf("Write after clear should succeed, but got %v", err)
+	}
+}
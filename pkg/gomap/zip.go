@@ -0,0 +1,22 @@
+package gomap
+
+import (
+	"fmt"
+)
+
+// ZipToMap returns a new BasicMap that maps keys[i] to values[i], or an error
+// wrapping ErrLengthMismatch if the slices have different lengths. If keys
+// contains duplicates, the last one wins.
+func ZipToMap(keys []interface{}, values []interface{}) (Map, error) {
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("%w: %d keys and %d values", ErrLengthMismatch, len(keys), len(values))
+	}
+
+	m := NewBasicMap(BasicMapParams{InitialCap: uint(len(keys))})
+
+	for ix, key := range keys {
+		m.Set(key, values[ix])
+	}
+
+	return m, nil
+}
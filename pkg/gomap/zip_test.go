@@ -0,0 +1,39 @@
+package gomap
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestZipToMap(t *testing.T) {
+	/// Setup
+	keys := []interface{}{"a", "b", "a"}
+	values := []interface{}{1, 2, 3}
+
+	/// When
+	m, err := ZipToMap(keys, values)
+
+	/// Then
+	if err != nil {
+		t.Fatalf("Should zip equal-length slices, but got %v", err)
+	}
+
+	if actual := goMapOf(m); !reflect.DeepEqual(actual, map[interface{}]interface{}{"a": 3, "b": 2}) {
+		t.Errorf("Should pair keys with values, last duplicate winning, but got %v", actual)
+	}
+}
+
+func TestZipToMapLengthMismatch(t *testing.T) {
+	/// Setup
+	keys := []interface{}{"a", "b"}
+	values := []interface{}{1}
+
+	/// When
+	m, err := ZipToMap(keys, values)
+
+	/// Then
+	if m != nil || !errors.Is(err, ErrLengthMismatch) {
+		t.Errorf("Should reject mismatched lengths, but got %v, %v", m, err)
+	}
+}